@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"log/slog"
+
+	"smartsearch/internal/vector"
+	"smartsearch/pkg/config"
+	"smartsearch/pkg/logging"
+
+	_ "github.com/lib/pq"
+)
+
+// fitreducer fits the configured dimensionality reducer over the tool corpus
+// (for the "pca" method) and re-embeds every tool with it, so indexed vectors
+// and queries are projected identically. It also resizes the embedding columns
+// when ollama.options.dimensionality changes.
+func main() {
+	configPath := flag.String("config", config.DefaultPath(), "path to a JSON or YAML config file")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize logging; the standard log package also writes through it
+	logger := logging.New(cfg)
+	slog.SetDefault(logger)
+
+	// Initialize PostgreSQL connection
+	db, err := sql.Open("postgres", cfg.PostgreSQL.DSN)
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	vectorStore := vector.NewVectorStore(db, cfg.Ollama.URL, cfg.Ollama.EmbedModel, cfg, logger)
+
+	// Fit a new projection when using PCA; other methods need no training.
+	// It is saved inactive and only activated once every tool is re-embedded.
+	var reducer *vector.PCAReducer
+	if cfg.Ollama.Options.Reduction == vector.ReductionPCA {
+		reducer, err = vectorStore.FitPCA(ctx)
+		if err != nil {
+			log.Fatalf("Failed to fit reducer: %v", err)
+		}
+		logger.Info("saved reducer", "version", reducer.Version())
+	}
+
+	// Re-embed all tools, resizing the embedding columns to the configured
+	// dimensionality and activating the new reducer in the same transaction
+	n, err := vectorStore.ReindexEmbeddings(ctx, reducer)
+	if err != nil {
+		log.Fatalf("Failed to re-embed tools: %v", err)
+	}
+
+	logger.Info("re-embedded tools", "count", n)
+}
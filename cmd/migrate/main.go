@@ -1,26 +1,37 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"flag"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"smartsearch/internal/vector"
 	"smartsearch/pkg/config"
+	"smartsearch/pkg/logging"
 
 	_ "github.com/lib/pq"
 )
 
 func main() {
+	configPath := flag.String("config", config.DefaultPath(), "path to a JSON or YAML config file")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.LoadConfig("config.json")
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Initialize logging; the standard log package also writes through it
+	logger := logging.New(cfg)
+	slog.SetDefault(logger)
+
 	// Connect to database
 	db, err := sql.Open("postgres", cfg.PostgreSQL.DSN)
 	if err != nil {
@@ -65,7 +76,7 @@ func main() {
 		}
 
 		if count > 0 {
-			fmt.Printf("Skipping %s (already applied)\n", file.Name())
+			logger.Info("skipping migration, already applied", "migration", file.Name())
 			continue
 		}
 
@@ -101,8 +112,15 @@ func main() {
 			log.Fatalf("Failed to commit migration %s: %v", file.Name(), err)
 		}
 
-		fmt.Printf("Applied migration: %s\n", file.Name())
+		logger.Info("applied migration", "migration", file.Name())
+	}
+
+	// Size the embedding columns for the configured dimensionality. Stored
+	// vectors of another size are left alone; cmd/fitreducer re-embeds them.
+	dims := cfg.Ollama.Options.Dimensionality
+	if err := vector.EnsureEmbeddingDimensions(context.Background(), db, dims); err != nil {
+		logger.Warn("embedding columns not resized", "dimensions", dims, "error", err)
 	}
 
-	fmt.Println("All migrations completed successfully")
-} 
\ No newline at end of file
+	logger.Info("all migrations completed successfully")
+}
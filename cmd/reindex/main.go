@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"log/slog"
+
+	"smartsearch/internal/vector"
+	"smartsearch/pkg/config"
+	"smartsearch/pkg/logging"
+	"smartsearch/pkg/search"
+
+	_ "github.com/lib/pq"
+	"github.com/opensearch-project/opensearch-go"
+)
+
+// reindex rebuilds the OpenSearch index from PostgreSQL into a new versioned
+// index, points the alias at it once it is complete and deletes old versions.
+// Run it after changing the index mapping or analyzers.
+func main() {
+	configPath := flag.String("config", config.DefaultPath(), "path to a JSON or YAML config file")
+	keep := flag.Int("keep", 1, "number of previous index versions to keep for rollback")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize logging; the standard log package also writes through it
+	logger := logging.New(cfg)
+	slog.SetDefault(logger)
+
+	// Initialize PostgreSQL connection
+	db, err := sql.Open("postgres", cfg.PostgreSQL.DSN)
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer db.Close()
+
+	// Initialize OpenSearch client
+	opensearchConfig := opensearch.Config{
+		Addresses: []string{cfg.OpenSearch.URL},
+		Username:  cfg.OpenSearch.Username,
+		Password:  cfg.OpenSearch.Password,
+	}
+	opensearchClient, err := opensearch.NewClient(opensearchConfig)
+	if err != nil {
+		log.Fatalf("Failed to create OpenSearch client: %v", err)
+	}
+
+	ctx := context.Background()
+	vectorStore := vector.NewVectorStore(db, cfg.Ollama.URL, cfg.Ollama.EmbedModel, cfg, logger)
+	searchClient := search.NewOpenSearchClient(opensearchClient, cfg.OpenSearch.Index, cfg, logger)
+
+	// PostgreSQL is the source of truth for the keyword index; tools written
+	// while this runs are replayed from it before the alias switches
+	index, count, err := searchClient.Reindex(ctx, vectorStore)
+	if err != nil {
+		log.Fatalf("Failed to reindex: %v", err)
+	}
+	logger.Info("reindexed tools", "index", index, "alias", cfg.OpenSearch.Index, "count", count)
+
+	deleted, err := searchClient.CleanupIndexes(ctx, *keep)
+	if err != nil {
+		log.Fatalf("Failed to delete old indexes: %v", err)
+	}
+	for _, name := range deleted {
+		logger.Info("deleted old index", "index", name)
+	}
+}
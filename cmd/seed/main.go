@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"log"
+	"log/slog"
 	"smartsearch/pkg/config"
+	"smartsearch/pkg/logging"
 	"smartsearch/pkg/search"
 	"smartsearch/seed"
 
@@ -13,12 +16,19 @@ import (
 )
 
 func main() {
+	configPath := flag.String("config", config.DefaultPath(), "path to a JSON or YAML config file")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.LoadConfig("config.json")
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Initialize logging; the standard log package also writes through it
+	logger := logging.New(cfg)
+	slog.SetDefault(logger)
+
 	// Initialize PostgreSQL connection
 	db, err := sql.Open("postgres", cfg.PostgreSQL.DSN)
 	if err != nil {
@@ -38,15 +48,15 @@ func main() {
 	}
 
 	// Initialize OpenSearch index with proper settings
-	osClient := search.NewOpenSearchClient(opensearchClient, cfg.OpenSearch.Index)
+	osClient := search.NewOpenSearchClient(opensearchClient, cfg.OpenSearch.Index, cfg, logger)
 	if err := osClient.CreateOrUpdateIndex(context.Background()); err != nil {
 		log.Fatalf("Failed to initialize OpenSearch index: %v", err)
 	}
 
 	// Run seeding
-	if err := seed.SeedDatabase(context.Background(), db, opensearchClient, cfg); err != nil {
+	if err := seed.SeedDatabase(context.Background(), db, opensearchClient, cfg, logger); err != nil {
 		log.Fatalf("Failed to seed database: %v", err)
 	}
 
-	log.Println("Database seeding completed successfully")
-} 
\ No newline at end of file
+	logger.Info("database seeding completed successfully")
+}
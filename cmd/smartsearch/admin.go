@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"smartsearch/pkg/logging"
+	"smartsearch/pkg/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerAdminRoutes registers the /admin endpoints behind requireAdminToken
+func registerAdminRoutes(router gin.IRouter, token string, reloader *search.SynonymReloader, logger *slog.Logger) {
+	admin := router.Group("/admin", requireAdminToken(token))
+
+	// Apply edits to the synonyms file by building a new index version in the
+	// background; GET reports its progress
+	admin.POST("/synonyms/reload", func(c *gin.Context) {
+		status, err := reloader.Start(c.Request.Context())
+		switch {
+		case errors.Is(err, search.ErrReloadInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": reloader.Status()})
+		case err != nil:
+			logging.FromContext(c.Request.Context(), logger).Error("synonym reload failed", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read synonyms file"})
+		default:
+			c.JSON(http.StatusAccepted, status)
+		}
+	})
+
+	admin.GET("/synonyms/reload", func(c *gin.Context) {
+		status := reloader.Status()
+		if status == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no synonym reload has run"})
+			return
+		}
+		c.JSON(http.StatusOK, status)
+	})
+}
+
+// requireAdminToken rejects requests without the bearer token. With no token
+// configured every request is rejected.
+func requireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin endpoints are disabled; set server.admin_token"})
+			return
+		}
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
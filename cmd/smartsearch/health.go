@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"smartsearch/internal/ollama"
+	"smartsearch/internal/vector"
+	"smartsearch/pkg/health"
+	"smartsearch/pkg/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerHealthRoutes adds liveness, which reports dependency status and mapping
+// drift but always returns 200, and readiness, which returns 503 until all pass.
+func registerHealthRoutes(router gin.IRouter, liveness, readiness *health.Checker) {
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, liveness.Run(c.Request.Context()))
+	})
+
+	router.GET("/readyz", func(c *gin.Context) {
+		report := readiness.Run(c.Request.Context())
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+}
+
+// dependencyChecks builds the liveness and readiness checkers. requiredModels
+// is called on each check so reloaded model names are picked up.
+func dependencyChecks(
+	vectorStore *vector.VectorStore,
+	searchClient *search.OpenSearchClient,
+	indexMonitor *search.IndexMonitor,
+	ollamaClient *ollama.Client,
+	requiredModels func() []string,
+) (liveness, readiness *health.Checker) {
+	liveness = health.NewChecker(0)
+	liveness.Add("postgres", vectorStore.Ping)
+	liveness.Add("opensearch", searchClient.Ping)
+	liveness.Add("opensearch_mapping", indexMonitor.Status)
+	liveness.Add("ollama", func(ctx context.Context) error {
+		_, err := ollamaClient.ListModels(ctx)
+		return err
+	})
+
+	readiness = health.NewChecker(0)
+	readiness.Add("postgres", func(ctx context.Context) error {
+		if err := vectorStore.Ping(ctx); err != nil {
+			return err
+		}
+		return vectorStore.CheckSchema(ctx)
+	})
+	readiness.Add("opensearch", func(ctx context.Context) error {
+		if err := searchClient.Ping(ctx); err != nil {
+			return err
+		}
+		return searchClient.CheckIndex(ctx)
+	})
+	readiness.Add("ollama", func(ctx context.Context) error {
+		available, err := ollamaClient.ListModels(ctx)
+		if err != nil {
+			return err
+		}
+		return missingModels(available, requiredModels())
+	})
+	return liveness, readiness
+}
+
+// missingModels reports required models that the server has not pulled.
+// Ollama lists untagged models with a ":latest" suffix.
+func missingModels(available, required []string) error {
+	have := make(map[string]bool, len(available))
+	for _, model := range available {
+		have[model] = true
+		have[strings.TrimSuffix(model, ":latest")] = true
+	}
+
+	var missing []string
+	for _, model := range required {
+		if model != "" && !have[model] {
+			missing = append(missing, model)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("models not available: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
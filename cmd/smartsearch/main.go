@@ -3,32 +3,53 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"smartsearch/internal/ollama"
 	"smartsearch/internal/query"
+	"smartsearch/internal/rerank"
 	"smartsearch/internal/vector"
 	"smartsearch/pkg/config"
-	"smartsearch/pkg/models"
+	"smartsearch/pkg/logging"
+	"smartsearch/pkg/metrics"
 	"smartsearch/pkg/search"
+	"smartsearch/pkg/tools"
+	"smartsearch/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"github.com/opensearch-project/opensearch-go"
+	"github.com/sashabaranov/go-openai"
 )
 
 func main() {
+	configPath := flag.String("config", config.DefaultPath(), "path to a JSON or YAML config file")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.LoadConfig("config.json")
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Initialize logging; the standard log package also writes through it
+	logger := logging.New(cfg)
+	slog.SetDefault(logger)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+
 	// Initialize PostgreSQL connection
 	db, err := sql.Open("postgres", cfg.PostgreSQL.DSN)
 	if err != nil {
@@ -47,33 +68,89 @@ func main() {
 		log.Fatalf("Failed to create OpenSearch client: %v", err)
 	}
 
+	// Initialize stores
+	vectorStore := vector.NewVectorStore(db, cfg.Ollama.URL, cfg.Ollama.EmbedModel, cfg, logger)
+	searchClient := search.NewOpenSearchClient(opensearchClient, cfg.OpenSearch.Index, cfg, logger)
+
+	// Initialize reranker against the OpenAI-compatible Ollama endpoint
+	rerankConfig := openai.DefaultConfig("ollama")
+	rerankConfig.BaseURL = cfg.Ollama.URL
+	reranker := rerank.NewReranker(openai.NewClientWithConfig(rerankConfig), rerankModel(cfg))
+
 	// Initialize search service
-	service := search.NewService(
-		query.NewQueryEngine(cfg.Ollama.URL, cfg.Ollama.ChatModel),
-		vector.NewVectorStore(db, cfg.Ollama.URL, cfg.Ollama.EmbedModel, cfg),
-		search.NewOpenSearchClient(opensearchClient, "tools"),
+	queryEngine := query.NewQueryEngine(cfg.Ollama.URL, cfg.Ollama.ChatModel, logger)
+	service, err := search.NewService(
+		queryEngine,
+		vectorStore,
+		searchClient,
+		reranker,
+		cfg,
+		logger,
 	)
+	if err != nil {
+		log.Fatalf("Failed to create search service: %v", err)
+	}
+
+	// Apply tuning changes from the config file or SIGHUP without restarting
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+
+	// Check the index mapping outside the request path
+	indexMonitor := search.NewIndexMonitor(
+		searchClient,
+		time.Duration(cfg.OpenSearch.MappingCheckIntervalMS)*time.Millisecond,
+		logger,
+	)
+
+	// Verify the index mapping once at startup, then track drift in the background
+	indexMonitor.Check(watchCtx)
+	go indexMonitor.Run(watchCtx)
+
+	watcher := config.NewWatcher(*configPath, cfg, logger)
+	go watcher.Run(watchCtx, func(next *config.Config) {
+		service.Reload(next)
+		queryEngine.SetModel(next.Ollama.ChatModel)
+		reranker.SetModel(rerankModel(next))
+	})
+
+	// Initialize tool registry
+	toolService := tools.NewService(vectorStore, searchClient)
 
 	// Initialize Gin router
-	router := gin.Default()
+	router := gin.New()
+	router.Use(gin.Recovery(), logging.Middleware(logger), metrics.Middleware(), tracing.Middleware())
 
 	// Register routes
-	router.POST("/search", func(c *gin.Context) {
-		var req models.SearchRequest
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
+	registerSearchRoutes(router, service)
+	registerToolRoutes(router, toolService)
+
+	// Register health endpoints
+	liveness, readiness := dependencyChecks(
+		vectorStore,
+		searchClient,
+		indexMonitor,
+		ollama.NewClient(cfg.Ollama.URL, cfg.Ollama.ChatModel),
+		func() []string {
+			current := watcher.Current()
+			return []string{current.Ollama.EmbedModel, current.Ollama.ChatModel, rerankModel(current)}
+		},
+	)
+	registerHealthRoutes(router, liveness, readiness)
+
+	// Expose Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-		results, err := service.Search(c.Request.Context(), req)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	router.GET("/stats/embedding-cache", func(c *gin.Context) {
+		stats := vectorStore.CacheStats()
+		if stats == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "embedding cache is disabled"})
 			return
 		}
-
-		c.JSON(http.StatusOK, results)
+		c.JSON(http.StatusOK, stats)
 	})
 
+	registerAdminRoutes(router, cfg.Server.AdminToken, search.NewSynonymReloader(searchClient, vectorStore), logger)
+
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
@@ -100,5 +177,17 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
-	log.Println("Server exiting")
-} 
\ No newline at end of file
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Warn("failed to flush traces", "error", err)
+	}
+
+	logger.Info("server exiting")
+}
+
+// rerankModel returns the configured rerank model, defaulting to the chat model
+func rerankModel(cfg *config.Config) string {
+	if cfg.Rerank.Model != "" {
+		return cfg.Rerank.Model
+	}
+	return cfg.Ollama.ChatModel
+}
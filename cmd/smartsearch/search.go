@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"smartsearch/pkg/logging"
+	"smartsearch/pkg/models"
+	"smartsearch/pkg/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerSearchRoutes registers the search endpoints on the router
+func registerSearchRoutes(router gin.IRouter, svc *search.Service) {
+	router.POST("/search", func(c *gin.Context) {
+		var req models.SearchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		results, err := svc.Search(c.Request.Context(), req)
+		if err != nil {
+			writeSearchError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, results)
+	})
+
+	// Stream results as Server-Sent Events: the fused page first, then each
+	// reranked result and the final page as they become available
+	stream := func(c *gin.Context) {
+		req, err := bindStreamRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Errors before the first event still get a status code; later ones
+		// are sent as an error event
+		started := false
+		emit := func(event string, data any) {
+			started = true
+			c.SSEvent(event, data)
+			c.Writer.Flush()
+		}
+
+		results, err := svc.SearchStream(c.Request.Context(), req, emit)
+		if err != nil && !started {
+			writeSearchError(c, err)
+			return
+		}
+		if err != nil {
+			logging.FromContext(c.Request.Context(), nil).Error("search failed", "error", err)
+			c.SSEvent("error", gin.H{"error": err.Error()})
+			return
+		}
+		c.SSEvent(search.EventResults, results)
+	}
+	router.GET("/search/stream", stream)
+	router.POST("/search/stream", stream)
+}
+
+// bindStreamRequest reads a search request from the JSON body of a POST or the
+// query string of a GET, where filters are a JSON-encoded parameter
+func bindStreamRequest(c *gin.Context) (models.SearchRequest, error) {
+	var req models.SearchRequest
+	if c.Request.Method != http.MethodGet {
+		err := c.ShouldBindJSON(&req)
+		return req, err
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return req, err
+	}
+	if filters := c.Query("filters"); filters != "" {
+		if err := json.Unmarshal([]byte(filters), &req.Filters); err != nil {
+			return req, errors.New("filters must be a JSON object")
+		}
+	}
+	return req, nil
+}
+
+// writeSearchError maps a search error to its HTTP status
+func writeSearchError(c *gin.Context, err error) {
+	if errors.Is(err, search.ErrInvalidRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logging.FromContext(c.Request.Context(), nil).Error("search failed", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
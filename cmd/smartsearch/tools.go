@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"smartsearch/pkg/logging"
+	"smartsearch/pkg/models"
+	"smartsearch/pkg/tools"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultToolPageSize = 50
+	maxToolPageSize     = 500
+)
+
+// registerToolRoutes registers the tool CRUD endpoints on the router
+func registerToolRoutes(router gin.IRouter, svc *tools.Service) {
+	group := router.Group("/tools")
+
+	group.GET("", func(c *gin.Context) {
+		limit, err := queryInt(c, "limit", defaultToolPageSize)
+		if err != nil || limit < 1 || limit > maxToolPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		offset, err := queryInt(c, "offset", 0)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return
+		}
+
+		page, err := svc.List(c.Request.Context(), c.Query("category"), limit, offset)
+		if err != nil {
+			writeToolError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	})
+
+	group.GET("/:id", func(c *gin.Context) {
+		tool, err := svc.Get(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			writeToolError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, tool)
+	})
+
+	group.POST("", func(c *gin.Context) {
+		var tool models.Tool
+		if err := c.ShouldBindJSON(&tool); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		created, err := svc.Create(c.Request.Context(), tool)
+		if err != nil {
+			writeToolError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, created)
+	})
+
+	group.PUT("/:id", func(c *gin.Context) {
+		var tool models.Tool
+		if err := c.ShouldBindJSON(&tool); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		stored, created, err := svc.Replace(c.Request.Context(), c.Param("id"), tool)
+		if err != nil {
+			writeToolError(c, err)
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		c.JSON(status, stored)
+	})
+
+	group.PATCH("/:id", func(c *gin.Context) {
+		var patch models.ToolPatch
+		if err := c.ShouldBindJSON(&patch); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tool, err := svc.Patch(c.Request.Context(), c.Param("id"), patch)
+		if err != nil {
+			writeToolError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, tool)
+	})
+
+	group.DELETE("/:id", func(c *gin.Context) {
+		if err := svc.Delete(c.Request.Context(), c.Param("id")); err != nil {
+			writeToolError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+// writeToolError maps tool service errors onto HTTP responses
+func writeToolError(c *gin.Context, err error) {
+	var validationErr *tools.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tool", "details": validationErr.Fields})
+	case errors.Is(err, tools.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, tools.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		// The error can carry SQL or OpenSearch details, so it is only logged
+		logging.FromContext(c.Request.Context(), nil).Error("tool request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
+
+// queryInt parses an integer query parameter, returning def when it is absent
+func queryInt(c *gin.Context, key string, def int) (int, error) {
+	v := c.Query(key)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
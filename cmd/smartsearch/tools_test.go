@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"smartsearch/pkg/tools"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWriteToolError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "validation",
+			err:        &tools.ValidationError{Fields: []tools.FieldError{{Field: "id", Message: "is required"}}},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"field":"id"`,
+		},
+		{name: "not found", err: tools.ErrNotFound, wantStatus: http.StatusNotFound, wantBody: "tool not found"},
+		{name: "conflict", err: tools.ErrAlreadyExists, wantStatus: http.StatusConflict, wantBody: "tool already exists"},
+		{
+			name:       "wrapped conflict",
+			err:        fmt.Errorf("create: %w", tools.ErrAlreadyExists),
+			wantStatus: http.StatusConflict,
+			wantBody:   "tool already exists",
+		},
+		{
+			name:       "internal errors are not echoed",
+			err:        errors.New(`pq: relation "tools" does not exist`),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/tools/x", nil)
+
+			writeToolError(c, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -3,11 +3,13 @@ package ollama
 import (
 	"context"
 	"fmt"
+	"smartsearch/pkg/tracing"
 	"strings"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 	"github.com/openai/openai-go/shared"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Client struct {
@@ -52,7 +54,13 @@ func NewClient(url, model string) *Client {
 	}
 }
 
-func (c *Client) GetChatCompletion(ctx context.Context, messages []ChatMessage) (string, error) {
+func (c *Client) GetChatCompletion(ctx context.Context, messages []ChatMessage) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "ollama.chat",
+		attribute.String("llm.model", c.model),
+		attribute.Int("llm.messages", len(messages)),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	// Convert our ChatMessage format to OpenAI's format
 	openAIMessages := make([]openai.ChatCompletionMessageParamUnion, len(messages))
 	for i, msg := range messages {
@@ -137,4 +145,18 @@ func (c *Client) GetEmbedding(ctx context.Context, text string) ([]float32, erro
 	}
 
 	return embedding, nil
-} 
\ No newline at end of file
+}
+
+// ListModels returns the IDs of the models available on the server
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	page, err := c.client.Models.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+
+	models := make([]string, len(page.Data))
+	for i, model := range page.Data {
+		models[i] = model.ID
+	}
+	return models, nil
+}
@@ -4,54 +4,66 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"smartsearch/internal/ollama"
+	"smartsearch/pkg/logging"
 	"smartsearch/pkg/models"
 	"strings"
+	"sync/atomic"
 )
 
 type QueryEngine struct {
-	client *ollama.Client
+	url    string
+	client atomic.Pointer[ollama.Client]
+	logger *slog.Logger
 }
 
-func NewQueryEngine(url, model string) *QueryEngine {
-	return &QueryEngine{
-		client: ollama.NewClient(url, model),
-	}
+func NewQueryEngine(url, model string, logger *slog.Logger) *QueryEngine {
+	qe := &QueryEngine{url: url, logger: logging.OrDefault(logger)}
+	qe.SetModel(model)
+	return qe
+}
+
+// SetModel switches the chat model used for query understanding. The Ollama
+// URL needs a restart. Requests already in flight keep the client they
+// started with.
+func (qe *QueryEngine) SetModel(model string) {
+	qe.client.Store(ollama.NewClient(qe.url, model))
 }
 
 func (qe *QueryEngine) UnderstandQuery(ctx context.Context, query string) (*models.QueryUnderstanding, error) {
 	// Get completion from Ollama
 	messages := []ollama.ChatMessage{
 		{
-			Role: "system",
-			Content: "You are a query understanding system for a tool search service. Always respond with valid JSON only. Rules for JSON response: 1. The response must be wrapped in ```json and ``` markers 2. No extra text, explanations, or thinking steps outside the JSON 3. No comments inside the JSON 4. No trailing commas 5. All strings must be double-quoted 6. No single quotes allowed 7. No extra whitespace or newlines inside the JSON Example response: ```json {\"query\": \"find all files modified in the last week\", \"intent\": \"search\", \"parameters\": {\"keywords\": [\"files\", \"modified\", \"last\", \"week\"], \"filters\": {\"modified_at\": \"last_week\"}}, \"confidence\": 0.9} ```",
+			Role:    "system",
+			Content: "You are a query understanding system for a tool search service. Always respond with valid JSON only. The JSON object has these fields: \"intent\": a short description of what the user wants to do; \"expanded_terms\": synonyms and related keywords that help keyword search find relevant tools; \"sub_queries\": when the request needs several distinct tools, one short search query per tool, otherwise an empty array; \"filters\": only constraints the user states explicitly, using \"category\" (a string) or \"tags\" (an array of strings), otherwise an empty object. Rules for JSON response: 1. The response must be wrapped in ```json and ``` markers 2. No extra text, explanations, or thinking steps outside the JSON 3. No comments inside the JSON 4. No trailing commas 5. All strings must be double-quoted 6. No single quotes allowed 7. No extra whitespace or newlines inside the JSON Example response: ```json {\"intent\": \"email a generated report\", \"expanded_terms\": [\"email\", \"mail\", \"gmail\", \"attachment\", \"report\"], \"sub_queries\": [\"generate a report\", \"send an email with an attachment\"], \"filters\": {}} ```",
 		},
 		{Role: "user", Content: query},
 	}
 
-	response, err := qe.client.GetChatCompletion(ctx, messages)
+	response, err := qe.client.Load().GetChatCompletion(ctx, messages)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get query understanding: %w", err)
 	}
 
-	// Debug raw response
-	fmt.Printf("Raw Ollama Response: %q\n", response)
+	logger := logging.FromContext(ctx, qe.logger)
+	logger.Debug("query understanding response", logging.ResponseKey, response)
 
 	// Clean up the response by removing thinking steps and extracting JSON
-	cleanedResponse := cleanOllamaResponse(response)
-	fmt.Printf("Cleaned Response: %q\n", cleanedResponse)
+	cleanedResponse := cleanOllamaResponse(logger, response)
 
 	// Parse response
 	var understanding models.QueryUnderstanding
 	if err := json.Unmarshal([]byte(cleanedResponse), &understanding); err != nil {
-		return nil, fmt.Errorf("failed to parse query understanding (response: %q): %w", cleanedResponse, err)
+		return nil, fmt.Errorf("failed to parse query understanding: %w", err)
 	}
 
 	return &understanding, nil
 }
+
 // cleanOllamaResponse removes thinking steps and extracts just the JSON content
-func cleanOllamaResponse(response string) string {
+func cleanOllamaResponse(logger *slog.Logger, response string) string {
 	// Remove thinking steps (content between <think> tags)
 	re := regexp.MustCompile(`<think>.*?</think>`)
 	cleaned := re.ReplaceAllString(response, "")
@@ -68,7 +80,7 @@ func cleanOllamaResponse(response string) string {
 
 	jsonEnd := strings.LastIndex(cleaned, "}")
 	if jsonEnd == -1 {
-		fmt.Printf("No JSON found in response: %q\n", cleaned)
+		logger.Warn("no JSON found in query understanding response", logging.ResponseKey, cleaned)
 		return "{}"
 	}
 
@@ -76,24 +88,22 @@ func cleanOllamaResponse(response string) string {
 	jsonEnd++
 
 	if jsonStart == -1 || jsonEnd == 0 {
-		fmt.Printf("No JSON found in response: %q\n", cleaned)
+		logger.Warn("no JSON found in query understanding response", logging.ResponseKey, cleaned)
 		return "{}"
 	}
 
 	jsonContent := cleaned[jsonStart:jsonEnd]
-	
+
 	// Clean up any remaining whitespace
 	jsonContent = strings.TrimSpace(jsonContent)
-	
+
 	// Validate that we have valid JSON
 	var test map[string]interface{}
 	if err := json.Unmarshal([]byte(jsonContent), &test); err != nil {
-		fmt.Printf("Invalid JSON found: %q\n", jsonContent)
+		logger.Warn("invalid JSON in query understanding response", logging.ResponseKey, jsonContent)
 		return "{}"
 	}
 
-	fmt.Print(jsonContent)
-
 	return jsonContent
 }
 
@@ -110,4 +120,4 @@ func (qe *QueryEngine) ExpandQuery(ctx context.Context, query string) ([]string,
 	expandedQueries = append(expandedQueries, understanding.SubQueries...)
 
 	return expandedQueries, nil
-} 
+}
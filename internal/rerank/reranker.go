@@ -3,23 +3,44 @@ package rerank
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"smartsearch/pkg/metrics"
 	"smartsearch/pkg/models"
 	"sort"
+	"sync/atomic"
 
 	"github.com/sashabaranov/go-openai"
 )
 
 type Reranker struct {
 	client *openai.Client
-	model  string
+	model  atomic.Pointer[string]
 }
 
 func NewReranker(client *openai.Client, model string) *Reranker {
-	return &Reranker{
-		client: client,
-		model:  model,
-	}
+	r := &Reranker{client: client}
+	r.SetModel(model)
+	return r
+}
+
+// Model returns the model used for reranking
+func (r *Reranker) Model() string {
+	return *r.model.Load()
+}
+
+// SetModel switches the model used for reranking
+func (r *Reranker) SetModel(model string) {
+	r.model.Store(&model)
+}
+
+// Ranking is the model's judgement of one result
+type Ranking struct {
+	ID            string  `json:"id"`
+	Score         float64 `json:"score"`
+	Confidence    float64 `json:"confidence"`
+	Justification string  `json:"justification"`
 }
 
 func (r *Reranker) Rerank(ctx context.Context, query string, results []models.SearchResult) ([]models.SearchResult, error) {
@@ -27,6 +48,94 @@ func (r *Reranker) Rerank(ctx context.Context, query string, results []models.Se
 		return results, nil
 	}
 
+	// Get completion from OpenAI
+	resp, err := r.client.CreateChatCompletion(ctx, r.request(query, results))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get re-ranking: %w", err)
+	}
+	metrics.TokensUsed.WithLabelValues(resp.Model, "prompt").Add(float64(resp.Usage.PromptTokens))
+	metrics.TokensUsed.WithLabelValues(resp.Model, "completion").Add(float64(resp.Usage.CompletionTokens))
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty re-ranking response")
+	}
+
+	// Parse response
+	var rankingResponse struct {
+		Rankings []Ranking `json:"rankings"`
+	}
+
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &rankingResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse re-ranking response: %w", err)
+	}
+
+	return applyRankings(results, rankingResponse.Rankings), nil
+}
+
+// RerankStream is Rerank with the model's answer streamed, calling onRanking
+// with each result's ranking as soon as the model has written it. Streamed responses
+// carry no token usage, so TokensUsed is not updated.
+func (r *Reranker) RerankStream(ctx context.Context, query string, results []models.SearchResult, onRanking func(Ranking)) ([]models.SearchResult, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	stream, err := r.client.CreateChatCompletionStream(ctx, r.request(query, results))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get re-ranking: %w", err)
+	}
+	defer stream.Close()
+
+	// Decode the rankings while the answer is still being written
+	pr, pw := io.Pipe()
+	type decoded struct {
+		rankings []Ranking
+		err      error
+	}
+	done := make(chan decoded, 1)
+	accept := firstRankings(results)
+	go func() {
+		rankings, err := decodeRankings(pr, func(ranking Ranking) {
+			if onRanking != nil && accept(ranking) {
+				onRanking(ranking)
+			}
+		})
+		if err == nil {
+			// Drain trailing whitespace so the writer never blocks
+			_, err = io.Copy(io.Discard, pr)
+		}
+		pr.CloseWithError(err)
+		done <- decoded{rankings, err}
+	}()
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			pw.CloseWithError(err)
+			<-done
+			return nil, fmt.Errorf("failed to get re-ranking: %w", err)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		if _, err := io.WriteString(pw, chunk.Choices[0].Delta.Content); err != nil {
+			// The decoder gave up; its error is returned below
+			break
+		}
+	}
+	pw.Close()
+
+	result := <-done
+	if result.err != nil {
+		return nil, fmt.Errorf("failed to parse re-ranking response: %w", result.err)
+	}
+	return applyRankings(results, result.rankings), nil
+}
+
+// request builds the chat completion request asking the model to rerank results
+func (r *Reranker) request(query string, results []models.SearchResult) openai.ChatCompletionRequest {
 	// Prepare candidates for re-ranking
 	candidates := make([]map[string]interface{}, len(results))
 	for i, result := range results {
@@ -52,49 +161,29 @@ Provide a JSON response with the following structure:
         {
             "id": "tool_id",
             "score": 0.95,
+            "confidence": 0.8,
             "justification": "Brief explanation of why this result is relevant"
         }
     ]
 }`, query, mustMarshal(candidates))
 
-	// Get completion from OpenAI
-	resp, err := r.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: r.model,
+	return openai.ChatCompletionRequest{
+		Model: *r.model.Load(),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: "You are a search result re-ranking system. Analyze the relevance of each result to the query and provide a new ranking with scores and justifications."},
 			{Role: "user", Content: prompt},
 		},
 		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: "json_object"},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get re-ranking: %w", err)
-	}
-
-	// Parse response
-	var rankingResponse struct {
-		Rankings []struct {
-			ID           string  `json:"id"`
-			Score        float64 `json:"score"`
-			Justification string `json:"justification"`
-		} `json:"rankings"`
-	}
-
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &rankingResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse re-ranking response: %w", err)
 	}
+}
 
-	// Create map of new rankings
-	newRankings := make(map[string]struct {
-		Score        float64
-		Justification string
-	})
-	for _, ranking := range rankingResponse.Rankings {
-		newRankings[ranking.ID] = struct {
-			Score        float64
-			Justification string
-		}{
-			Score:        ranking.Score,
-			Justification: ranking.Justification,
+// applyRankings scores results with the model's rankings and sorts them
+func applyRankings(results []models.SearchResult, rankings []Ranking) []models.SearchResult {
+	// Create map of new rankings, keeping the first ranking of each result
+	newRankings := make(map[string]Ranking, len(rankings))
+	for _, ranking := range rankings {
+		if _, ok := newRankings[ranking.ID]; !ok {
+			newRankings[ranking.ID] = ranking
 		}
 	}
 
@@ -102,17 +191,95 @@ Provide a JSON response with the following structure:
 	for i := range results {
 		if newRanking, ok := newRankings[results[i].Tool.ID]; ok {
 			results[i].RerankedScore = newRanking.Score
+			results[i].Confidence = newRanking.Confidence
 			results[i].Justification = newRanking.Justification
 			results[i].Score = newRanking.Score // Update final score with re-ranked score
 		}
 	}
 
-	// Sort results by new score
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
+	// Sort results by new score, keeping results the model did not rank after
+	// the ranked ones in their original order
+	sort.SliceStable(results, func(i, j int) bool {
+		_, rankedI := newRankings[results[i].Tool.ID]
+		_, rankedJ := newRankings[results[j].Tool.ID]
+		if rankedI != rankedJ {
+			return rankedI
+		}
+		return rankedI && results[i].Score > results[j].Score
 	})
 
-	return results, nil
+	return results
+}
+
+// firstRankings returns a filter that accepts the first ranking of each
+// result, matching the rankings applyRankings uses. Rankings of IDs the model
+// invented or repeated are dropped.
+func firstRankings(results []models.SearchResult) func(Ranking) bool {
+	pending := make(map[string]bool, len(results))
+	for _, result := range results {
+		pending[result.Tool.ID] = true
+	}
+	return func(ranking Ranking) bool {
+		if !pending[ranking.ID] {
+			return false
+		}
+		delete(pending, ranking.ID)
+		return true
+	}
+}
+
+// decodeRankings reads a {"rankings": [...]} answer as it arrives, calling
+// onRanking with each ranking once it is complete
+func decodeRankings(r io.Reader, onRanking func(Ranking)) ([]Ranking, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	var rankings []Ranking
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key != "rankings" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := expectDelim(dec, '['); err != nil {
+			return nil, err
+		}
+		for dec.More() {
+			var ranking Ranking
+			if err := dec.Decode(&ranking); err != nil {
+				return nil, err
+			}
+			rankings = append(rankings, ranking)
+			if onRanking != nil {
+				onRanking(ranking)
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, err
+		}
+	}
+	return rankings, expectDelim(dec, '}')
+}
+
+// expectDelim reads the next token and checks that it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
 }
 
 func mustMarshal(v interface{}) string {
@@ -121,4 +288,4 @@ func mustMarshal(v interface{}) string {
 		panic(err)
 	}
 	return string(b)
-} 
\ No newline at end of file
+}
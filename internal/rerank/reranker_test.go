@@ -0,0 +1,172 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"smartsearch/pkg/models"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// candidates builds results with the given IDs in fused order
+func candidates(ids ...string) []models.SearchResult {
+	results := make([]models.SearchResult, len(ids))
+	for i, id := range ids {
+		results[i] = models.SearchResult{Tool: models.Tool{ID: id}, Score: 1 - float64(i)/10}
+	}
+	return results
+}
+
+func order(results []models.SearchResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.Tool.ID
+	}
+	return ids
+}
+
+func TestDecodeRankings(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "rankings", answer: `{"rankings": [{"id": "a", "score": 0.9}, {"id": "b", "score": 0.4}]}`, want: []string{"a", "b"}},
+		{name: "other keys skipped", answer: `{"note": {"x": [1, 2]}, "rankings": [{"id": "a"}], "done": true}`, want: []string{"a"}},
+		{name: "empty rankings", answer: `{"rankings": []}`},
+		{name: "no rankings", answer: `{}`},
+		{name: "not an object", answer: `[{"id": "a"}]`, wantErr: true},
+		{name: "rankings not an array", answer: `{"rankings": {"id": "a"}}`, wantErr: true},
+		{name: "truncated", answer: `{"rankings": [{"id": "a"}, {"id": "b"`, want: []string{"a"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Read one byte at a time, as a streamed answer arrives
+			var streamed []string
+			rankings, err := decodeRankings(iotest.OneByteReader(strings.NewReader(tt.answer)), func(r Ranking) {
+				streamed = append(streamed, r.ID)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(streamed, tt.want) {
+				t.Errorf("streamed %v, want %v", streamed, tt.want)
+			}
+			if err == nil && len(rankings) != len(tt.want) {
+				t.Errorf("returned %d rankings, want %d", len(rankings), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestApplyRankings(t *testing.T) {
+	tests := []struct {
+		name     string
+		rankings []Ranking
+		want     []string
+	}{
+		{name: "reorders", rankings: []Ranking{{ID: "a", Score: 0.2}, {ID: "b", Score: 0.9}, {ID: "c", Score: 0.5}}, want: []string{"b", "c", "a"}},
+		{name: "unranked keep fused order after ranked", rankings: []Ranking{{ID: "c", Score: 0.1}}, want: []string{"c", "a", "b"}},
+		{name: "unknown ids ignored", rankings: []Ranking{{ID: "x", Score: 1}, {ID: "b", Score: 0.5}}, want: []string{"b", "a", "c"}},
+		{name: "first ranking of a duplicate wins", rankings: []Ranking{{ID: "a", Score: 0.1}, {ID: "b", Score: 0.5}, {ID: "a", Score: 0.9}}, want: []string{"b", "a", "c"}},
+		{name: "no rankings", want: []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := applyRankings(candidates("a", "b", "c"), tt.rankings)
+			if got := order(results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	results := applyRankings(candidates("a", "b"), []Ranking{{ID: "b", Score: 0.8, Confidence: 0.7, Justification: "sends mail"}})
+	want := models.SearchResult{Tool: models.Tool{ID: "b"}, Score: 0.8, RerankedScore: 0.8, Confidence: 0.7, Justification: "sends mail"}
+	if !reflect.DeepEqual(results[0], want) {
+		t.Errorf("ranked result %+v, want %+v", results[0], want)
+	}
+	if results[1].Score != 1 || results[1].RerankedScore != 0 {
+		t.Errorf("unranked result %+v changed", results[1])
+	}
+}
+
+// streamServer answers chat completion requests with answer, streamed in
+// chunks of a few bytes
+func streamServer(t *testing.T, answer string) *Reranker {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for len(answer) > 0 {
+			n := min(7, len(answer))
+			chunk, _ := json.Marshal(openai.ChatCompletionStreamResponse{
+				Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: answer[:n]}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			answer = answer[n:]
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL
+	return NewReranker(openai.NewClientWithConfig(config), "test-model")
+}
+
+func TestRerankStream(t *testing.T) {
+	answer := `{"rankings": [
+		{"id": "b", "score": 0.9, "confidence": 0.8, "justification": "best"},
+		{"id": "x", "score": 0.8, "confidence": 0.8, "justification": "not a candidate"},
+		{"id": "a", "score": 0.4, "confidence": 0.5, "justification": "ok"},
+		{"id": "b", "score": 0.1, "confidence": 0.1, "justification": "repeated"}
+	]}`
+	reranker := streamServer(t, answer)
+
+	var streamed []Ranking
+	results, err := reranker.RerankStream(context.Background(), "q", candidates("a", "b", "c"), func(r Ranking) {
+		streamed = append(streamed, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantStreamed := []Ranking{
+		{ID: "b", Score: 0.9, Confidence: 0.8, Justification: "best"},
+		{ID: "a", Score: 0.4, Confidence: 0.5, Justification: "ok"},
+	}
+	if !reflect.DeepEqual(streamed, wantStreamed) {
+		t.Errorf("streamed %+v, want %+v", streamed, wantStreamed)
+	}
+	if got, want := order(results), []string{"b", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order %v, want %v", got, want)
+	}
+	if results[0].Score != 0.9 || results[0].Justification != "best" {
+		t.Errorf("b = %+v, want the first ranking applied", results[0])
+	}
+}
+
+func TestRerankStreamMalformed(t *testing.T) {
+	reranker := streamServer(t, `{"rankings": [{"id": "a", "score": 0.9}, {"id": `)
+
+	var streamed []string
+	_, err := reranker.RerankStream(context.Background(), "q", candidates("a", "b"), func(r Ranking) {
+		streamed = append(streamed, r.ID)
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to parse re-ranking response") {
+		t.Errorf("error = %v, want a parse error", err)
+	}
+	// Rankings completed before the answer broke off were already sent
+	if !reflect.DeepEqual(streamed, []string{"a"}) {
+		t.Errorf("streamed %v, want [a]", streamed)
+	}
+}
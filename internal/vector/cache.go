@@ -0,0 +1,325 @@
+package vector
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"smartsearch/pkg/logging"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lib/pq"
+	pgvector "github.com/pgvector/pgvector-go"
+)
+
+// CacheKey identifies an embedding by model name and SHA-256 of the input text
+type CacheKey struct {
+	Model string
+	Hash  string
+}
+
+func NewCacheKey(model, text string) CacheKey {
+	sum := sha256.Sum256([]byte(text))
+	return CacheKey{Model: model, Hash: hex.EncodeToString(sum[:])}
+}
+
+// EmbeddingCache stores raw model embeddings. Implementations must be safe for
+// concurrent use.
+type EmbeddingCache interface {
+	// GetMany returns the cached embeddings for the keys that are present
+	GetMany(ctx context.Context, keys []CacheKey) (map[CacheKey][]float32, error)
+	// PutMany stores embeddings, overwriting or ignoring existing entries
+	PutMany(ctx context.Context, entries map[CacheKey][]float32) error
+}
+
+// LRUCache is an in-memory cache that evicts the least recently used entries
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[CacheKey]*list.Element
+}
+
+type lruEntry struct {
+	key       CacheKey
+	embedding []float32
+}
+
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[CacheKey]*list.Element),
+	}
+}
+
+func (c *LRUCache) GetMany(_ context.Context, keys []CacheKey) (map[CacheKey][]float32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	found := make(map[CacheKey][]float32)
+	for _, key := range keys {
+		if el, ok := c.entries[key]; ok {
+			c.order.MoveToFront(el)
+			found[key] = el.Value.(*lruEntry).embedding
+		}
+	}
+	return found, nil
+}
+
+func (c *LRUCache) PutMany(_ context.Context, entries map[CacheKey][]float32) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, embedding := range entries {
+		if el, ok := c.entries[key]; ok {
+			el.Value.(*lruEntry).embedding = embedding
+			c.order.MoveToFront(el)
+			continue
+		}
+		c.entries[key] = c.order.PushFront(&lruEntry{key: key, embedding: embedding})
+		for c.order.Len() > c.capacity {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*lruEntry).key)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of cached embeddings
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// PostgresCache persists embeddings in the embedding_cache table
+type PostgresCache struct {
+	db *sql.DB
+}
+
+func NewPostgresCache(db *sql.DB) *PostgresCache {
+	return &PostgresCache{db: db}
+}
+
+func (c *PostgresCache) GetMany(ctx context.Context, keys []CacheKey) (map[CacheKey][]float32, error) {
+	// Group hashes by model so each model needs a single query
+	byModel := make(map[string][]string)
+	for _, key := range keys {
+		byModel[key.Model] = append(byModel[key.Model], key.Hash)
+	}
+
+	found := make(map[CacheKey][]float32)
+	for model, hashes := range byModel {
+		rows, err := c.db.QueryContext(ctx, `
+			SELECT text_hash, embedding
+			FROM embedding_cache
+			WHERE model = $1 AND text_hash = ANY($2::text[])
+		`, model, pq.Array(hashes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query embedding cache: %w", err)
+		}
+
+		for rows.Next() {
+			var hash string
+			var vec pgvector.Vector
+			if err := rows.Scan(&hash, &vec); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan cached embedding: %w", err)
+			}
+			found[CacheKey{Model: model, Hash: hash}] = vec.Slice()
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating cached embeddings: %w", err)
+		}
+	}
+
+	return found, nil
+}
+
+func (c *PostgresCache) PutMany(ctx context.Context, entries map[CacheKey][]float32) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for key, embedding := range entries {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO embedding_cache (model, text_hash, embedding)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (model, text_hash) DO NOTHING
+		`, key.Model, key.Hash, pgvector.NewVector(embedding))
+		if err != nil {
+			return fmt.Errorf("failed to store cached embedding: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cached embeddings: %w", err)
+	}
+	return nil
+}
+
+// TieredCache checks each layer in order and copies hits from lower layers
+// into the layers above them
+type TieredCache []EmbeddingCache
+
+func (t TieredCache) GetMany(ctx context.Context, keys []CacheKey) (map[CacheKey][]float32, error) {
+	found := make(map[CacheKey][]float32)
+	missing := keys
+
+	for i, layer := range t {
+		if len(missing) == 0 {
+			break
+		}
+
+		hits, err := layer.GetMany(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		if len(hits) == 0 {
+			continue
+		}
+
+		// Promote hits into the faster layers
+		for _, upper := range t[:i] {
+			if err := upper.PutMany(ctx, hits); err != nil {
+				return nil, err
+			}
+		}
+
+		remaining := missing[:0:0]
+		for _, key := range missing {
+			if embedding, ok := hits[key]; ok {
+				found[key] = embedding
+			} else {
+				remaining = append(remaining, key)
+			}
+		}
+		missing = remaining
+	}
+
+	return found, nil
+}
+
+func (t TieredCache) PutMany(ctx context.Context, entries map[CacheKey][]float32) error {
+	for _, layer := range t {
+		if err := layer.PutMany(ctx, entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Embedder produces embeddings for text
+type Embedder interface {
+	GetEmbedding(ctx context.Context, text string) ([]float32, error)
+	GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// CacheStats reports embedding cache effectiveness
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// CachedEmbedder serves embeddings from a cache and only sends misses to the
+// underlying embedder. Cache failures are logged and treated as misses.
+type CachedEmbedder struct {
+	next   Embedder
+	model  string
+	cache  EmbeddingCache
+	logger *slog.Logger
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func NewCachedEmbedder(next Embedder, model string, cache EmbeddingCache, logger *slog.Logger) *CachedEmbedder {
+	return &CachedEmbedder{
+		next:   next,
+		model:  model,
+		cache:  cache,
+		logger: logging.OrDefault(logger),
+	}
+}
+
+func (e *CachedEmbedder) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.GetEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e *CachedEmbedder) GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([]CacheKey, len(texts))
+	for i, text := range texts {
+		keys[i] = NewCacheKey(e.model, text)
+	}
+
+	cached, err := e.cache.GetMany(ctx, keys)
+	if err != nil {
+		logging.FromContext(ctx, e.logger).Warn("embedding cache lookup failed", "error", err)
+		cached = map[CacheKey][]float32{}
+	}
+
+	// Embed each distinct missing text once
+	var missTexts []string
+	var missKeys []CacheKey
+	pending := make(map[CacheKey]bool)
+	for i, key := range keys {
+		if _, ok := cached[key]; ok || pending[key] {
+			continue
+		}
+		pending[key] = true
+		missTexts = append(missTexts, texts[i])
+		missKeys = append(missKeys, key)
+	}
+
+	e.hits.Add(int64(len(texts) - len(missTexts)))
+	e.misses.Add(int64(len(missTexts)))
+
+	if len(missTexts) > 0 {
+		embedded, err := e.next.GetEmbeddings(ctx, missTexts)
+		if err != nil {
+			return nil, err
+		}
+
+		fresh := make(map[CacheKey][]float32, len(embedded))
+		for i, embedding := range embedded {
+			fresh[missKeys[i]] = embedding
+			cached[missKeys[i]] = embedding
+		}
+		if err := e.cache.PutMany(ctx, fresh); err != nil {
+			logging.FromContext(ctx, e.logger).Warn("failed to store embeddings in cache", "error", err)
+		}
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for i, key := range keys {
+		embeddings[i] = cached[key]
+	}
+	return embeddings, nil
+}
+
+// Stats returns the cache hit and miss counts since startup
+func (e *CachedEmbedder) Stats() CacheStats {
+	stats := CacheStats{Hits: e.hits.Load(), Misses: e.misses.Load()}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
@@ -0,0 +1,198 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewCacheKey(t *testing.T) {
+	a := NewCacheKey("nomic", "send email")
+	if a != NewCacheKey("nomic", "send email") {
+		t.Error("keys for the same model and text differ")
+	}
+	if a == NewCacheKey("mxbai", "send email") {
+		t.Error("keys for different models match")
+	}
+	if a == NewCacheKey("nomic", "send emails") {
+		t.Error("keys for different texts match")
+	}
+	if len(a.Hash) != 64 {
+		t.Errorf("hash %q is not hex SHA-256", a.Hash)
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	key := func(s string) CacheKey { return NewCacheKey("m", s) }
+	vec := func(v float32) []float32 { return []float32{v} }
+
+	tests := []struct {
+		name    string
+		ops     func(c *LRUCache)
+		present []string
+		absent  []string
+	}{
+		{
+			name: "evicts the oldest entry",
+			ops: func(c *LRUCache) {
+				c.PutMany(ctx, map[CacheKey][]float32{key("a"): vec(1)})
+				c.PutMany(ctx, map[CacheKey][]float32{key("b"): vec(2)})
+				c.PutMany(ctx, map[CacheKey][]float32{key("c"): vec(3)})
+			},
+			present: []string{"b", "c"},
+			absent:  []string{"a"},
+		},
+		{
+			name: "reads refresh recency",
+			ops: func(c *LRUCache) {
+				c.PutMany(ctx, map[CacheKey][]float32{key("a"): vec(1)})
+				c.PutMany(ctx, map[CacheKey][]float32{key("b"): vec(2)})
+				c.GetMany(ctx, []CacheKey{key("a")})
+				c.PutMany(ctx, map[CacheKey][]float32{key("c"): vec(3)})
+			},
+			present: []string{"a", "c"},
+			absent:  []string{"b"},
+		},
+		{
+			name: "overwrites refresh recency",
+			ops: func(c *LRUCache) {
+				c.PutMany(ctx, map[CacheKey][]float32{key("a"): vec(1)})
+				c.PutMany(ctx, map[CacheKey][]float32{key("b"): vec(2)})
+				c.PutMany(ctx, map[CacheKey][]float32{key("a"): vec(9)})
+				c.PutMany(ctx, map[CacheKey][]float32{key("c"): vec(3)})
+			},
+			present: []string{"a", "c"},
+			absent:  []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLRUCache(2)
+			tt.ops(c)
+			if c.Len() != len(tt.present) {
+				t.Errorf("Len() = %d, want %d", c.Len(), len(tt.present))
+			}
+			for _, s := range tt.present {
+				if got, _ := c.GetMany(ctx, []CacheKey{key(s)}); got[key(s)] == nil {
+					t.Errorf("%s was evicted", s)
+				}
+			}
+			for _, s := range tt.absent {
+				if got, _ := c.GetMany(ctx, []CacheKey{key(s)}); got[key(s)] != nil {
+					t.Errorf("%s is still cached", s)
+				}
+			}
+		})
+	}
+
+	c := NewLRUCache(2)
+	c.PutMany(ctx, map[CacheKey][]float32{key("a"): vec(1)})
+	c.PutMany(ctx, map[CacheKey][]float32{key("a"): vec(9)})
+	if got, _ := c.GetMany(ctx, []CacheKey{key("a")}); !reflect.DeepEqual(got[key("a")], vec(9)) {
+		t.Errorf("overwritten value = %v, want [9]", got[key("a")])
+	}
+}
+
+func TestTieredCachePromotesHits(t *testing.T) {
+	ctx := context.Background()
+	upper, lower := NewLRUCache(10), NewLRUCache(10)
+	k := NewCacheKey("m", "text")
+	lower.PutMany(ctx, map[CacheKey][]float32{k: {1, 2}})
+
+	got, err := TieredCache{upper, lower}.GetMany(ctx, []CacheKey{k, NewCacheKey("m", "missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[k], []float32{1, 2}) {
+		t.Errorf("GetMany = %v", got)
+	}
+	if upper.Len() != 1 {
+		t.Error("hit from the lower layer was not promoted")
+	}
+}
+
+// fakeEmbedder embeds each text as its length and counts the texts it is sent
+type fakeEmbedder struct {
+	calls int
+	err   error
+}
+
+func (f *fakeEmbedder) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := f.GetEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (f *fakeEmbedder) GetEmbeddings(_ context.Context, texts []string) ([][]float32, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls += len(texts)
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = []float32{float32(len(text))}
+	}
+	return embeddings, nil
+}
+
+// failingCache fails every operation
+type failingCache struct{}
+
+func (failingCache) GetMany(context.Context, []CacheKey) (map[CacheKey][]float32, error) {
+	return nil, errors.New("down")
+}
+
+func (failingCache) PutMany(context.Context, map[CacheKey][]float32) error {
+	return errors.New("down")
+}
+
+func TestCachedEmbedder(t *testing.T) {
+	ctx := context.Background()
+	next := &fakeEmbedder{}
+	e := NewCachedEmbedder(next, "m", NewLRUCache(10), nil)
+
+	got, err := e.GetEmbeddings(ctx, []string{"a", "bb", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{1}, {2}, {1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("embeddings = %v, want %v", got, want)
+	}
+	if next.calls != 2 {
+		t.Errorf("embedded %d texts, want each distinct text once", next.calls)
+	}
+
+	if _, err := e.GetEmbeddings(ctx, []string{"bb", "ccc"}); err != nil {
+		t.Fatal(err)
+	}
+	if next.calls != 3 {
+		t.Errorf("embedded %d texts, want cached text skipped", next.calls)
+	}
+	// "a" repeated within a batch and "bb" the second time are hits
+	if stats := e.Stats(); stats.Hits != 2 || stats.Misses != 3 || stats.HitRate != 0.4 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestCachedEmbedderCacheFailure(t *testing.T) {
+	next := &fakeEmbedder{}
+	e := NewCachedEmbedder(next, "m", failingCache{}, nil)
+
+	got, err := e.GetEmbeddings(context.Background(), []string{"abc"})
+	if err != nil {
+		t.Fatalf("cache failure should fall back to the embedder: %v", err)
+	}
+	if !reflect.DeepEqual(got, [][]float32{{3}}) {
+		t.Errorf("embeddings = %v", got)
+	}
+
+	next.err = errors.New("ollama down")
+	if _, err := e.GetEmbeddings(context.Background(), []string{"abc"}); !errors.Is(err, next.err) {
+		t.Errorf("error = %v, want the embedder's error", err)
+	}
+}
@@ -1,9 +1,29 @@
 package vector
 
 import (
+	"fmt"
+	"math"
+	"time"
+
 	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// Reduction methods accepted in config
+const (
+	ReductionNone     = "none"
+	ReductionTruncate = "truncate"
+	ReductionPCA      = "pca"
 )
 
+// Reducer maps raw model embeddings into the stored vector space. The same
+// reducer version must be used at index and query time; the version is stored
+// with every tool row so vectors from different reducers are never compared.
+type Reducer interface {
+	Version() string
+	Reduce(vec []float32) ([]float32, error)
+}
+
 // floats32To64 converts []float32 to []float64
 func floats32To64(x []float32) []float64 {
 	y := make([]float64, len(x))
@@ -22,28 +42,120 @@ func floats64To32(x []float64) []float32 {
 	return y
 }
 
-// reduceDimensions reduces the dimensionality of a vector using SVD
-func reduceDimensions(vec []float32, targetDim int) []float32 {
-	if len(vec) <= targetDim {
-		return vec
+// NoneReducer passes embeddings through unchanged
+type NoneReducer struct {
+	Dim int
+}
+
+func (r NoneReducer) Version() string { return ReductionNone }
+
+func (r NoneReducer) Reduce(vec []float32) ([]float32, error) {
+	if r.Dim > 0 && len(vec) != r.Dim {
+		return nil, fmt.Errorf("embedding has %d dimensions, expected %d; configure a reduction method", len(vec), r.Dim)
+	}
+	return vec, nil
+}
+
+// TruncateReducer keeps the leading dimensions and renormalizes to unit length.
+// This is only meaningful for Matryoshka-trained models, whose leading
+// dimensions carry most of the signal.
+type TruncateReducer struct {
+	Dim int
+}
+
+func (r TruncateReducer) Version() string { return fmt.Sprintf("%s-%d", ReductionTruncate, r.Dim) }
+
+func (r TruncateReducer) Reduce(vec []float32) ([]float32, error) {
+	if len(vec) < r.Dim {
+		return nil, fmt.Errorf("embedding has %d dimensions, cannot truncate to %d", len(vec), r.Dim)
+	}
+
+	out := make([]float32, r.Dim)
+	copy(out, vec[:r.Dim])
+
+	var norm float64
+	for _, v := range out {
+		norm += float64(v) * float64(v)
+	}
+	if norm = math.Sqrt(norm); norm > 0 {
+		for i := range out {
+			out[i] = float32(float64(out[i]) / norm)
+		}
+	}
+	return out, nil
+}
+
+// PCAReducer projects centered embeddings onto principal components fitted
+// over the tool corpus
+type PCAReducer struct {
+	version    string
+	mean       []float64
+	components *mat.Dense // inputDim × outputDim, one component per column
+}
+
+func (r *PCAReducer) Version() string { return r.version }
+
+// Dims returns the input and output dimensionality of the projection
+func (r *PCAReducer) Dims() (input, output int) {
+	return r.components.Dims()
+}
+
+func (r *PCAReducer) Reduce(vec []float32) ([]float32, error) {
+	in, out := r.Dims()
+	if len(vec) != in {
+		return nil, fmt.Errorf("embedding has %d dimensions, reducer %s expects %d", len(vec), r.version, in)
 	}
 
-	// Convert input vector to matrix format (1×N)
-	m := mat.NewDense(1, len(vec), floats32To64(vec))
+	centered := floats32To64(vec)
+	for i := range centered {
+		centered[i] -= r.mean[i]
+	}
 
-	// Perform SVD
-	var svd mat.SVD
-	ok := svd.Factorize(m, mat.SVDThin)
-	if !ok {
-		// If SVD fails, return truncated vector
-		return vec[:targetDim]
+	proj := mat.NewVecDense(out, nil)
+	proj.MulVec(r.components.T(), mat.NewVecDense(in, centered))
+
+	return floats64To32(proj.RawVector().Data), nil
+}
+
+// FitPCA fits a PCA projection from raw embeddings to targetDim dimensions.
+// PCA cannot produce more components than there are samples, so the corpus must
+// contain at least targetDim embeddings; use truncation for smaller corpora.
+func FitPCA(vectors [][]float32, targetDim int) (*PCAReducer, error) {
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no embeddings to fit")
+	}
+	inputDim := len(vectors[0])
+	if targetDim > inputDim {
+		return nil, fmt.Errorf("target dimension %d exceeds embedding dimension %d", targetDim, inputDim)
+	}
+	if len(vectors) < targetDim {
+		return nil, fmt.Errorf("need at least %d embeddings to fit %d components, got %d", targetDim, targetDim, len(vectors))
 	}
 
-	// Project to target dimensions using U matrix
-	u := mat.NewDense(1, len(vec), nil)
-	svd.UTo(u)
-	proj := mat.NewDense(1, targetDim, nil)
-	proj.Mul(m, u.Slice(0, len(vec), 0, targetDim))
+	data := mat.NewDense(len(vectors), inputDim, nil)
+	for i, vec := range vectors {
+		if len(vec) != inputDim {
+			return nil, fmt.Errorf("embedding %d has %d dimensions, expected %d", i, len(vec), inputDim)
+		}
+		data.SetRow(i, floats32To64(vec))
+	}
 
-	return floats64To32(proj.RawRowView(0))
-} 
\ No newline at end of file
+	var pc stat.PC
+	if ok := pc.PrincipalComponents(data, nil); !ok {
+		return nil, fmt.Errorf("principal component analysis failed")
+	}
+	var vecs mat.Dense
+	pc.VectorsTo(&vecs)
+
+	mean := make([]float64, inputDim)
+	for j := range mean {
+		mean[j] = stat.Mean(mat.Col(nil, j, data), nil)
+	}
+
+	components := mat.DenseCopyOf(vecs.Slice(0, inputDim, 0, targetDim))
+	return &PCAReducer{
+		version:    fmt.Sprintf("%s-%d-%s", ReductionPCA, targetDim, time.Now().UTC().Format("20060102T150405Z")),
+		mean:       mean,
+		components: components,
+	}, nil
+}
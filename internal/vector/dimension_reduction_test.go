@@ -0,0 +1,97 @@
+package vector
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReducers(t *testing.T) {
+	tests := []struct {
+		name    string
+		reducer Reducer
+		in      []float32
+		want    []float32
+		wantErr string
+	}{
+		{name: "none", reducer: NoneReducer{Dim: 3}, in: []float32{1, 2, 3}, want: []float32{1, 2, 3}},
+		{name: "none, wrong size", reducer: NoneReducer{Dim: 2}, in: []float32{1, 2, 3}, wantErr: "configure a reduction method"},
+		{name: "truncate renormalizes", reducer: TruncateReducer{Dim: 2}, in: []float32{3, 4, 12}, want: []float32{0.6, 0.8}},
+		{name: "truncate zero vector", reducer: TruncateReducer{Dim: 2}, in: []float32{0, 0, 1}, want: []float32{0, 0}},
+		{name: "truncate too short", reducer: TruncateReducer{Dim: 4}, in: []float32{1, 2}, wantErr: "cannot truncate to 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.reducer.Reduce(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Reduce(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	if v := (TruncateReducer{Dim: 256}).Version(); v != "truncate-256" {
+		t.Errorf("truncate version = %q", v)
+	}
+}
+
+func TestFitPCA(t *testing.T) {
+	// Points along the x = y diagonal with a little spread across it, so the
+	// first component is the diagonal
+	vectors := [][]float32{{1, 1.1, 0}, {2, 1.9, 0}, {3, 3.1, 0}, {4, 3.9, 0}, {5, 5, 0}}
+	r, err := FitPCA(vectors, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in, out := r.Dims(); in != 3 || out != 1 {
+		t.Errorf("Dims() = %d, %d, want 3, 1", in, out)
+	}
+	if !strings.HasPrefix(r.Version(), "pca-1-") {
+		t.Errorf("version = %q", r.Version())
+	}
+
+	// Distances along the diagonal are preserved; the mean maps to zero
+	a, _ := r.Reduce([]float32{3, 3, 0})
+	b, _ := r.Reduce([]float32{4, 4, 0})
+	if math.Abs(float64(a[0])) > 0.05 {
+		t.Errorf("mean projected to %v, want about 0", a[0])
+	}
+	if d := math.Abs(float64(b[0] - a[0])); math.Abs(d-math.Sqrt2) > 0.05 {
+		t.Errorf("projected distance %v, want about %v", d, math.Sqrt2)
+	}
+
+	if _, err := r.Reduce([]float32{1, 2}); err == nil {
+		t.Error("expected an error for an embedding of the wrong size")
+	}
+}
+
+func TestFitPCAErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		vectors   [][]float32
+		targetDim int
+		want      string
+	}{
+		{name: "no vectors", want: "no embeddings"},
+		{name: "target too large", vectors: [][]float32{{1, 2}, {3, 4}, {5, 6}}, targetDim: 3, want: "exceeds embedding dimension"},
+		{name: "too few samples", vectors: [][]float32{{1, 2, 3}}, targetDim: 2, want: "need at least 2 embeddings"},
+		{name: "ragged", vectors: [][]float32{{1, 2}, {3}}, targetDim: 1, want: "embedding 1 has 1 dimensions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FitPCA(tt.vectors, tt.targetDim); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package vector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// EnsureEmbeddingDimensions sizes the embedding columns for dims-dimensional
+// vectors and creates their ANN indexes. It fails when stored embeddings have
+// another size; ReindexEmbeddings re-embeds them and resizes the columns in
+// one transaction.
+func EnsureEmbeddingDimensions(ctx context.Context, db *sql.DB, dims int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := resizeEmbeddings(ctx, tx, dims, false); err != nil {
+		return err
+	}
+	if err := createEmbeddingIndexes(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit embedding dimensions: %w", err)
+	}
+	return nil
+}
+
+// resizeEmbeddings changes the embedding columns to dims dimensions, dropping
+// their ANN indexes and clearing stored vectors when clear is set. It reports
+// whether the columns changed.
+func resizeEmbeddings(ctx context.Context, tx *sql.Tx, dims int, clear bool) (bool, error) {
+	var resized bool
+	err := tx.QueryRowContext(ctx, `SELECT resize_embeddings($1, $2)`, dims, clear).Scan(&resized)
+	if err != nil {
+		return false, fmt.Errorf("failed to resize embeddings to %d dimensions: %w", dims, err)
+	}
+	return resized, nil
+}
+
+// createEmbeddingIndexes creates any missing ANN indexes on the embedding columns
+func createEmbeddingIndexes(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `SELECT create_embedding_indexes()`); err != nil {
+		return fmt.Errorf("failed to create embedding indexes: %w", err)
+	}
+	return nil
+}
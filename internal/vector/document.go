@@ -0,0 +1,119 @@
+package vector
+
+import (
+	"bytes"
+	"fmt"
+	"smartsearch/pkg/models"
+	"smartsearch/pkg/schema"
+	"strings"
+	"text/template"
+)
+
+// Vector field names stored in tool_vectors
+const (
+	FieldDocument     = "document"
+	FieldName         = "name"
+	FieldDescription  = "description"
+	fieldInputPrefix  = "input:"
+	fieldOutputPrefix = "output:"
+)
+
+// maxParameterFields caps the per-parameter vectors indexed for a single tool
+const maxParameterFields = 64
+
+// DefaultDocumentTemplate renders the whole tool definition, including
+// flattened parameter descriptions, into embedding text
+const DefaultDocumentTemplate = `{{.Name}}
+{{.Description}}
+{{- if .Category}}
+Category: {{.Category}}{{end}}
+{{- if .Tags}}
+Tags: {{join .Tags ", "}}{{end}}
+{{- if .Inputs}}
+Inputs:{{range .Inputs}}
+- {{.Path}}{{if .Type}} ({{.Type}}){{end}}{{if .Description}}: {{.Description}}{{end}}{{end}}{{end}}
+{{- if .Outputs}}
+Outputs:{{range .Outputs}}
+- {{.Path}}{{if .Type}} ({{.Type}}){{end}}{{if .Description}}: {{.Description}}{{end}}{{end}}{{end}}`
+
+// DocumentData is the value passed to the document template
+type DocumentData struct {
+	models.Tool
+	Inputs  []schema.Property
+	Outputs []schema.Property
+}
+
+// DocumentRenderer turns a tool into the texts that are embedded for it
+type DocumentRenderer struct {
+	tmpl *template.Template
+	err  error
+}
+
+// NewDocumentRenderer parses the document template, using the default when
+// text is empty. A parse error is returned by every Render call so indexing
+// fails loudly instead of silently embedding the wrong text.
+func NewDocumentRenderer(text string) *DocumentRenderer {
+	if text == "" {
+		text = DefaultDocumentTemplate
+	}
+	tmpl, err := template.New("document").
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		err = fmt.Errorf("invalid embedding document template: %w", err)
+	}
+	return &DocumentRenderer{tmpl: tmpl, err: err}
+}
+
+// Render returns the document text for a tool
+func (r *DocumentRenderer) Render(tool models.Tool) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+
+	var buf bytes.Buffer
+	err := r.tmpl.Execute(&buf, DocumentData{
+		Tool:    tool,
+		Inputs:  schema.Flatten(tool.InputSchema),
+		Outputs: schema.Flatten(tool.OutputSchema),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render embedding document for tool %s: %w", tool.ID, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Fields returns the per-field texts used for multi-vector indexing, keyed by
+// field name. Each schema parameter gets its own field so a query naming a
+// parameter can match the tool directly.
+func (r *DocumentRenderer) Fields(tool models.Tool) map[string]string {
+	fields := map[string]string{
+		FieldName:        tool.Name,
+		FieldDescription: tool.Description,
+	}
+
+	n := 0
+	add := func(prefix string, props []schema.Property) {
+		for _, p := range props {
+			if n == maxParameterFields {
+				return
+			}
+			text := p.Path
+			if p.Description != "" {
+				text += ": " + p.Description
+			}
+			fields[prefix+p.Path] = text
+			n++
+		}
+	}
+	add(fieldInputPrefix, schema.Flatten(tool.InputSchema))
+	add(fieldOutputPrefix, schema.Flatten(tool.OutputSchema))
+
+	for name, text := range fields {
+		if strings.TrimSpace(text) == "" {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
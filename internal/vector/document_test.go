@@ -0,0 +1,135 @@
+package vector
+
+import (
+	"fmt"
+	"reflect"
+	"smartsearch/pkg/models"
+	"strings"
+	"testing"
+)
+
+func documentTool() models.Tool {
+	return models.Tool{
+		ID:          "mail.send",
+		Name:        "Send email",
+		Description: "Sends an email message",
+		Category:    "email",
+		Tags:        []string{"email", "smtp"},
+		InputSchema: map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"to"},
+			"properties": map[string]interface{}{
+				"to":   map[string]interface{}{"type": "string", "description": "Recipient address"},
+				"body": map[string]interface{}{"type": "string"},
+			},
+		},
+		OutputSchema: map[string]interface{}{
+			"properties": map[string]interface{}{
+				"id": map[string]interface{}{"type": "string", "description": "Message ID"},
+			},
+		},
+	}
+}
+
+func TestDocumentRendererRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		tool     func() models.Tool
+		want     string
+		wantErr  string
+	}{
+		{
+			name: "default template",
+			tool: documentTool,
+			want: `Send email
+Sends an email message
+Category: email
+Tags: email, smtp
+Inputs:
+- body (string)
+- to (string): Recipient address
+Outputs:
+- id (string): Message ID`,
+		},
+		{
+			name: "default template skips empty sections",
+			tool: func() models.Tool { return models.Tool{Name: "Ping", Description: "Checks a host"} },
+			want: "Ping\nChecks a host",
+		},
+		{
+			name:     "custom template",
+			template: `{{.Name}} [{{.Category}}] {{len .Inputs}} inputs`,
+			tool:     documentTool,
+			want:     "Send email [email] 2 inputs",
+		},
+		{
+			name:     "surrounding whitespace trimmed",
+			template: "\n  {{.Name}}  \n",
+			tool:     documentTool,
+			want:     "Send email",
+		},
+		{
+			name:     "parse error",
+			template: `{{.Name`,
+			tool:     documentTool,
+			wantErr:  "invalid embedding document template",
+		},
+		{
+			name:     "unknown field",
+			template: `{{.Owner}}`,
+			tool:     documentTool,
+			wantErr:  "tool mail.send",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDocumentRenderer(tt.template).Render(tt.tool())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentRendererFields(t *testing.T) {
+	renderer := NewDocumentRenderer("")
+
+	got := renderer.Fields(documentTool())
+	want := map[string]string{
+		FieldName:        "Send email",
+		FieldDescription: "Sends an email message",
+		"input:body":     "body",
+		"input:to":       "to: Recipient address",
+		"output:id":      "id: Message ID",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %v, want %v", got, want)
+	}
+
+	// Blank fields are dropped
+	got = renderer.Fields(models.Tool{Name: "Ping", Description: "  "})
+	if !reflect.DeepEqual(got, map[string]string{FieldName: "Ping"}) {
+		t.Errorf("Fields() = %v, want only the name", got)
+	}
+
+	// Parameter fields are capped
+	props := make(map[string]interface{})
+	for i := 0; i < maxParameterFields+10; i++ {
+		props[fmt.Sprintf("p%03d", i)] = map[string]interface{}{"type": "string"}
+	}
+	got = renderer.Fields(models.Tool{Name: "Wide", InputSchema: map[string]interface{}{"properties": props}})
+	if len(got) != maxParameterFields+1 {
+		t.Errorf("got %d fields, want %d parameters plus the name", len(got), maxParameterFields)
+	}
+}
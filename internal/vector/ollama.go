@@ -6,18 +6,35 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"smartsearch/pkg/metrics"
+	"smartsearch/pkg/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultBatchSize   = 64
+	defaultBatchTokens = 8192
 )
 
+// BatchOptions limits the size of a single embedding request
+type BatchOptions struct {
+	Size   int // maximum number of texts per request
+	Tokens int // approximate maximum number of tokens per request
+}
+
 type OllamaClient struct {
-	url   string
-	model string
+	url        string
+	model      string
+	batch      BatchOptions
+	httpClient *http.Client
 }
 
 type EmbeddingRequest struct {
-	Model  string `json:"model"`
-	Input  string `json:"input"`
+	Model string   `json:"model"`
+	Input []string `json:"input"`
 }
 
 type EmbeddingData struct {
@@ -36,17 +53,89 @@ type EmbeddingResponse struct {
 	} `json:"usage"`
 }
 
-func NewOllamaClient(url, model string) *OllamaClient {
+func NewOllamaClient(url, model string, batch BatchOptions) *OllamaClient {
+	if batch.Size <= 0 {
+		batch.Size = defaultBatchSize
+	}
+	if batch.Tokens <= 0 {
+		batch.Tokens = defaultBatchTokens
+	}
+
+	// A single client keeps connections to Ollama alive across requests
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 16
+	transport.IdleConnTimeout = 90 * time.Second
+
 	return &OllamaClient{
-		url:   url,
-		model: model,
+		url:        url,
+		model:      model,
+		batch:      batch,
+		httpClient: &http.Client{Transport: transport},
 	}
 }
 
 func (c *OllamaClient) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := c.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetEmbeddings embeds many texts, splitting them into requests that respect the
+// configured batch size and token budget. Embeddings are returned in input order.
+func (c *OllamaClient) GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for _, batch := range c.batches(texts) {
+		batchEmbeddings, err := c.embed(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batchEmbeddings...)
+	}
+
+	return embeddings, nil
+}
+
+// batches splits texts into consecutive chunks within the batch limits. A text
+// larger than the token budget is sent on its own.
+func (c *OllamaClient) batches(texts []string) [][]string {
+	var batches [][]string
+	var current []string
+	tokens := 0
+
+	for _, text := range texts {
+		t := estimateTokens(text)
+		if len(current) > 0 && (len(current) == c.batch.Size || tokens+t > c.batch.Tokens) {
+			batches = append(batches, current)
+			current, tokens = nil, 0
+		}
+		current = append(current, text)
+		tokens += t
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+// estimateTokens approximates the token count of text at four bytes per token
+func estimateTokens(text string) int {
+	return len(text)/4 + 1
+}
+
+// embed sends a single embedding request for all of texts
+func (c *OllamaClient) embed(ctx context.Context, texts []string) (_ [][]float32, err error) {
+	ctx, span := tracing.Start(ctx, "ollama.embeddings",
+		attribute.String("llm.model", c.model),
+		attribute.Int("llm.inputs", len(texts)),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	reqBody := EmbeddingRequest{
 		Model: c.model,
-		Input: text,
+		Input: texts,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -54,15 +143,13 @@ func (c *OllamaClient) GetEmbedding(ctx context.Context, text string) ([]float32
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-
 	req, err := http.NewRequestWithContext(ctx, "POST", c.url+"/embeddings", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -79,15 +166,29 @@ func (c *OllamaClient) GetEmbedding(ctx context.Context, text string) ([]float32
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if len(embeddingResp.Data) == 0 {
-		return nil, fmt.Errorf("empty embedding data in response")
+	metrics.TokensUsed.WithLabelValues(c.model, "prompt").Add(float64(embeddingResp.Usage.PromptTokens))
+	span.SetAttributes(attribute.Int("llm.prompt_tokens", embeddingResp.Usage.PromptTokens))
+
+	if len(embeddingResp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings in response, got %d", len(texts), len(embeddingResp.Data))
 	}
 
-	embedding := embeddingResp.Data[0].Embedding
-	if len(embedding) == 0 {
-		return nil, fmt.Errorf("empty embedding vector in response")
+	// Place each embedding by its index; the API does not guarantee order
+	embeddings := make([][]float32, len(texts))
+	for _, data := range embeddingResp.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		if len(data.Embedding) == 0 {
+			return nil, fmt.Errorf("empty embedding vector in response")
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
 	}
 
-	log.Printf("Received embedding with %d dimensions", len(embedding))
-	return embedding, nil
-} 
\ No newline at end of file
+	return embeddings, nil
+}
@@ -4,150 +4,626 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"smartsearch/pkg/config"
+	"smartsearch/pkg/filter"
+	"smartsearch/pkg/logging"
+	"smartsearch/pkg/metrics"
 	"smartsearch/pkg/models"
+	"smartsearch/pkg/tracing"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/lib/pq"
 	pgvector "github.com/pgvector/pgvector-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// ErrToolNotFound is returned when a tool does not exist in the store
+	ErrToolNotFound = errors.New("tool not found")
+	// ErrToolExists is returned by CreateTool when the ID is taken
+	ErrToolExists = errors.New("tool already exists")
+)
+
+// toolColumns lists the tool columns in the order expected by scanTool
+const toolColumns = `t.id, t.name, t.description, t.category, t.tags,
+			t.input_schema, t.output_schema, t.version,
+			t.created_at, t.updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// SearchOptions tunes the approximate nearest neighbour index for a single query.
+// Zero values leave the database settings in place.
+type SearchOptions struct {
+	EfSearch int            // hnsw.ef_search
+	Probes   int            // ivfflat.probes
+	Filter   *filter.Filter // restricts the candidate tools
+	Explain  bool           // attach the distance and rank to each result
+
+	// IterativeScan is the pgvector iterative scan mode: "off",
+	// "relaxed_order" or "strict_order"
+	IterativeScan string
+}
+
+// Iterative scan modes for SearchOptions.IterativeScan
+const (
+	IterativeScanOff     = "off"
+	IterativeScanRelaxed = "relaxed_order"
+	IterativeScanStrict  = "strict_order"
+)
+
+const (
+	defaultEmbeddingCacheSize = 10000
+	reducerRefreshInterval    = time.Minute
+
+	// multiVectorOverfetch is how many field vectors are scanned per result
+	// in multi-vector search
+	multiVectorOverfetch = 8
 )
 
 type VectorStore struct {
-	db           *sql.DB
-	ollamaClient *OllamaClient
-	targetDim    int
+	db        *sql.DB
+	embedder  Embedder
+	cache     *CachedEmbedder
+	model     string
+	targetDim int
+	reduction string
+	logger    *slog.Logger
+
+	renderer    *DocumentRenderer
+	multiVector bool
+
+	reducerMu        sync.RWMutex
+	reducer          Reducer
+	reducerCheckedAt time.Time
+}
+
+func NewVectorStore(db *sql.DB, ollamaURL, model string, cfg *config.Config, logger *slog.Logger) *VectorStore {
+	vs := &VectorStore{
+		db: db,
+		embedder: NewOllamaClient(ollamaURL, model, BatchOptions{
+			Size:   cfg.Ollama.Options.EmbedBatchSize,
+			Tokens: cfg.Ollama.Options.EmbedBatchTokens,
+		}),
+		model:       model,
+		targetDim:   cfg.Ollama.Options.Dimensionality,
+		reduction:   cfg.Ollama.Options.Reduction,
+		renderer:    NewDocumentRenderer(cfg.Embedding.Template),
+		multiVector: cfg.Embedding.MultiVector,
+		logger:      logging.OrDefault(logger),
+	}
+
+	// Put the embedding cache in front of Ollama
+	if cfg.EmbeddingCache.Enabled {
+		size := cfg.EmbeddingCache.Size
+		if size <= 0 {
+			size = defaultEmbeddingCacheSize
+		}
+		layers := TieredCache{NewLRUCache(size)}
+		if cfg.EmbeddingCache.Persistent {
+			layers = append(layers, NewPostgresCache(db))
+		}
+		vs.cache = NewCachedEmbedder(vs.embedder, model, layers, vs.logger)
+		vs.embedder = vs.cache
+	}
+
+	return vs
+}
+
+// CacheStats returns embedding cache statistics, or nil when caching is disabled
+func (vs *VectorStore) CacheStats() *CacheStats {
+	if vs.cache == nil {
+		return nil
+	}
+	stats := vs.cache.Stats()
+	return &stats
+}
+
+// Ping checks that PostgreSQL is reachable
+func (vs *VectorStore) Ping(ctx context.Context) error {
+	if err := vs.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
+// CheckSchema checks that the tools table exists
+func (vs *VectorStore) CheckSchema(ctx context.Context) error {
+	var exists bool
+	if err := vs.db.QueryRowContext(ctx, `SELECT to_regclass('tools') IS NOT NULL`).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check tools table: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("tools table does not exist; run migrations")
+	}
+	return nil
+}
+
+// getReducer returns the reducer for the configured method. A fitted PCA
+// reducer is loaded from the database on first use.
+func (vs *VectorStore) getReducer(ctx context.Context) (Reducer, error) {
+	vs.reducerMu.RLock()
+	reducer, checkedAt := vs.reducer, vs.reducerCheckedAt
+	vs.reducerMu.RUnlock()
+	if reducer != nil {
+		// Fitted reducers can be replaced by cmd/fitreducer while the server runs
+		if vs.reduction != ReductionPCA || time.Since(checkedAt) < reducerRefreshInterval {
+			return reducer, nil
+		}
+		if version, err := ActivePCAVersion(ctx, vs.db, vs.model); err != nil || version == reducer.Version() {
+			if err != nil {
+				logging.FromContext(ctx, vs.logger).Warn("failed to check active reducer", "error", err)
+			}
+			vs.setReducer(reducer)
+			return reducer, nil
+		}
+	}
+
+	switch vs.reduction {
+	case "", ReductionNone:
+		reducer = NoneReducer{Dim: vs.targetDim}
+	case ReductionTruncate:
+		reducer = TruncateReducer{Dim: vs.targetDim}
+	case ReductionPCA:
+		pca, err := LoadPCAReducer(ctx, vs.db, vs.model)
+		if errors.Is(err, ErrNoReducer) {
+			return nil, fmt.Errorf("%w for model %s; run cmd/fitreducer", err, vs.model)
+		}
+		if err != nil {
+			return nil, err
+		}
+		reducer = pca
+	default:
+		return nil, fmt.Errorf("unknown reduction method %q", vs.reduction)
+	}
+
+	vs.setReducer(reducer)
+	return reducer, nil
 }
 
-func NewVectorStore(db *sql.DB, ollamaURL, model string, cfg *config.Config) *VectorStore {
-	return &VectorStore{
-		db:           db,
-		ollamaClient: NewOllamaClient(ollamaURL, model),
-		targetDim:    cfg.Ollama.Options.Dimensionality,
+// currentReducer is getReducer without the refresh delay, for writes whose
+// vectors must match the active reducer
+func (vs *VectorStore) currentReducer(ctx context.Context) (Reducer, error) {
+	reducer, err := vs.getReducer(ctx)
+	if err != nil || !vs.reducerReplaced(ctx, reducer.Version()) {
+		return reducer, err
 	}
+	return vs.getReducer(ctx)
 }
 
-func (vs *VectorStore) getEmbedding(ctx context.Context, text string) ([]float32, error) {
-	embedding, err := vs.ollamaClient.GetEmbedding(ctx, text)
+func (vs *VectorStore) setReducer(reducer Reducer) {
+	vs.reducerMu.Lock()
+	vs.reducer = reducer
+	vs.reducerCheckedAt = time.Now()
+	vs.reducerMu.Unlock()
+}
+
+// getEmbedding embeds text and reduces it into the stored vector space,
+// returning the reducer version it was reduced with
+func (vs *VectorStore) getEmbedding(ctx context.Context, text string) ([]float32, string, error) {
+	embeddings, version, err := vs.getEmbeddings(ctx, []string{text})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get embedding: %w", err)
+		return nil, "", err
 	}
-	log.Printf("Generated embedding with %d dimensions", len(embedding))
+	return embeddings[0], version, nil
+}
 
-	// Reduce dimensions if needed
-	if len(embedding) > vs.targetDim {
-		embedding = reduceDimensions(embedding, vs.targetDim)
+// getEmbeddings embeds texts in batches and reduces them into the stored vector space
+func (vs *VectorStore) getEmbeddings(ctx context.Context, texts []string) ([][]float32, string, error) {
+	reducer, err := vs.getReducer(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	embeddings, err := vs.embedWith(ctx, reducer, texts)
+	if err != nil {
+		return nil, "", err
+	}
+	return embeddings, reducer.Version(), nil
+}
+
+// embedWith embeds texts in batches and reduces them with reducer
+func (vs *VectorStore) embedWith(ctx context.Context, reducer Reducer, texts []string) ([][]float32, error) {
+	start := time.Now()
+	embeddings, err := vs.embedder.GetEmbeddings(ctx, texts)
+	metrics.ObserveStage(metrics.StageEmbedding, start)
+	if err != nil {
+		metrics.DependencyError(metrics.DependencyOllama)
+		return nil, fmt.Errorf("failed to get embeddings: %w", err)
+	}
+
+	for i, embedding := range embeddings {
+		reduced, err := reducer.Reduce(embedding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reduce embedding: %w", err)
+		}
+		embeddings[i] = reduced
 	}
 
-	return embedding, nil
+	return embeddings, nil
 }
-func (vs *VectorStore) Search(ctx context.Context, query string, k int) ([]models.SearchResult, error) {
-	log.Print("query: ", query)
+
+func (vs *VectorStore) Search(ctx context.Context, query string, k int, opts SearchOptions) (results []models.SearchResult, err error) {
+	ctx, span := tracing.Start(ctx, "pgvector.search",
+		attribute.Int("search.k", k),
+		attribute.Bool("pgvector.multi_vector", vs.multiVector),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("search.results", len(results)))
+		tracing.End(span, err)
+	}()
+
+	logger := logging.FromContext(ctx, vs.logger)
+	logger.Debug("vector search", logging.QueryKey, query, "k", k)
+
 	// Get embedding for query
-	embedding, err := vs.getEmbedding(ctx, query)
+	embedding, reducerVersion, err := vs.getEmbedding(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err = vs.nearestTools(ctx, embedding, reducerVersion, k, opts)
+	if err == nil && len(results) == 0 && vs.reducerReplaced(ctx, reducerVersion) {
+		// cmd/fitreducer activated a new reducer since it was last checked, so
+		// no stored vector has the old version; retry with the new one
+		embedding, reducerVersion, err = vs.getEmbedding(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		results, err = vs.nearestTools(ctx, embedding, reducerVersion, k, opts)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	logger.Debug("vector search results", "count", len(results))
+	return results, nil
+}
+
+// reducerReplaced reports whether the active PCA reducer is no longer
+// version, dropping the cached reducer so the next lookup loads the new one
+func (vs *VectorStore) reducerReplaced(ctx context.Context, version string) bool {
+	if vs.reduction != ReductionPCA {
+		return false
+	}
+	active, err := ActivePCAVersion(ctx, vs.db, vs.model)
+	if err != nil || active == version {
+		return false
+	}
+
+	vs.reducerMu.Lock()
+	if vs.reducer != nil && vs.reducer.Version() == version {
+		vs.reducer = nil
+	}
+	vs.reducerMu.Unlock()
+	return true
+}
+
+// nearestTools returns the k tools whose vectors from reducerVersion are
+// closest to embedding
+func (vs *VectorStore) nearestTools(ctx context.Context, embedding []float32, reducerVersion string, k int, opts SearchOptions) (results []models.SearchResult, err error) {
+	logger := logging.FromContext(ctx, vs.logger)
+
 	// Convert embedding to pgvector format
 	vec := pgvector.NewVector(embedding)
-	log.Printf("Query vector created with %d dimensions", len(embedding))
 
-	// Search for the k most similar tools by cosine distance
-	rows, err := vs.db.QueryContext(ctx, `
-		SELECT 
-			t.id, t.name, t.description, t.category, t.tags,
-			t.input_schema, t.output_schema, t.version,
-			t.created_at, t.updated_at,
-			(t.embedding <=> $1::vector) AS distance
-		FROM tools t
-		WHERE t.embedding IS NOT NULL
+	start := time.Now()
+	defer metrics.ObserveStage(metrics.StagePgvector, start)
+
+	// Index tuning parameters are set with SET LOCAL, so they only apply inside this transaction
+	tx, err := vs.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		metrics.DependencyError(metrics.DependencyPostgres)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := vs.applySearchOptions(ctx, tx, opts); err != nil {
+		return nil, err
+	}
+
+	// Search for the k most similar tools by cosine distance, only comparing
+	// against vectors produced by the same reducer
+	where, filterArgs := opts.Filter.SQL(4)
+	// The outer ORDER BY restores exact order after a relaxed iterative scan.
+	searchSQL := `
+		SELECT * FROM (
+			SELECT ` + toolColumns + `,
+				(t.embedding <=> $1::vector) AS distance
+			FROM tools t
+			WHERE t.embedding IS NOT NULL AND t.reducer_version = $3` + where + `
+			ORDER BY distance ASC
+			LIMIT $2
+		) ranked
+		ORDER BY distance ASC
+	`
+	if vs.multiVector {
+		// A tool's distance is that of its closest field vector. The nearest
+		// vectors come from an ANN scan over tool_vectors, over-fetched since
+		// one tool can own several of them, and are then reduced to tools.
+		searchSQL = `
+		SELECT * FROM (
+			SELECT DISTINCT ON (t.id) ` + toolColumns + `, nearest.distance
+			FROM (
+				SELECT v.tool_id, (v.embedding <=> $1::vector) AS distance
+				FROM tool_vectors v
+				JOIN tools t ON t.id = v.tool_id
+				WHERE v.reducer_version = $3` + where + `
+				ORDER BY distance ASC
+				LIMIT $2 * ` + strconv.Itoa(multiVectorOverfetch) + `
+			) nearest
+			JOIN tools t ON t.id = nearest.tool_id
+			ORDER BY t.id, nearest.distance ASC
+		) ranked
 		ORDER BY distance ASC
 		LIMIT $2
-	`, vec, 5)
+	`
+	}
+	rows, err := tx.QueryContext(ctx, searchSQL, append([]interface{}{vec, k, reducerVersion}, filterArgs...)...)
 	if err != nil {
-		log.Printf("Query error: %v", err)
+		metrics.DependencyError(metrics.DependencyPostgres)
 		return nil, fmt.Errorf("failed to query vector store: %w", err)
 	}
 	defer rows.Close()
 
-	var results []models.SearchResult
 	for rows.Next() {
-		var tool models.Tool
 		var distance float64
-		var inputSchemaJSON, outputSchemaJSON []byte
-		err := rows.Scan(
-			&tool.ID, &tool.Name, &tool.Description, &tool.Category, pq.Array(&tool.Tags),
-			&inputSchemaJSON, &outputSchemaJSON, &tool.Version,
-			&tool.CreatedAt, &tool.UpdatedAt,
-			&distance,
-		)
+		tool, err := scanTool(rows, &distance)
 		if err != nil {
-			log.Printf("Error scanning row: %v", err)
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		// Unmarshal JSON schemas
-		if len(inputSchemaJSON) > 0 {
-			if err := json.Unmarshal(inputSchemaJSON, &tool.InputSchema); err != nil {
-				log.Printf("Error unmarshaling input schema: %v", err)
-				return nil, fmt.Errorf("failed to unmarshal input schema: %w", err)
-			}
-		}
-		if len(outputSchemaJSON) > 0 {
-			if err := json.Unmarshal(outputSchemaJSON, &tool.OutputSchema); err != nil {
-				log.Printf("Error unmarshaling output schema: %v", err)
-				return nil, fmt.Errorf("failed to unmarshal output schema: %w", err)
-			}
+			return nil, err
 		}
 
 		// Convert distance to similarity score (1 - distance)
 		similarity := 1 - distance
-		log.Printf("Found tool: %s with distance: %f, similarity: %f", tool.Name, distance, similarity)
-		results = append(results, models.SearchResult{
-			Tool:        tool,
+		logger.Debug("vector hit", "tool_id", tool.ID, "distance", distance, "similarity", similarity)
+		result := models.SearchResult{
+			Tool:        *tool,
 			VectorScore: similarity,
 			Score:       similarity,
-		})
+		}
+		if opts.Explain {
+			result.Explanation = &models.Explanation{Vector: &models.LegExplanation{
+				Rank:     len(results) + 1,
+				Score:    similarity,
+				Distance: &distance,
+			}}
+		}
+		results = append(results, result)
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating rows: %v", err)
+		metrics.DependencyError(metrics.DependencyPostgres)
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	log.Printf("Total results found: %d", len(results))
 	return results, nil
 }
 
+// applySearchOptions sets the per-transaction index tuning parameters
+func (vs *VectorStore) applySearchOptions(ctx context.Context, tx *sql.Tx, opts SearchOptions) error {
+	// SET does not accept bind parameters; the values are integers so formatting is safe
+	if opts.EfSearch > 0 {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", opts.EfSearch)); err != nil {
+			return fmt.Errorf("failed to set hnsw.ef_search: %w", err)
+		}
+	}
+	if opts.Probes > 0 {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL ivfflat.probes = %d", opts.Probes)); err != nil {
+			return fmt.Errorf("failed to set ivfflat.probes: %w", err)
+		}
+	}
+
+	// The reducer version and any filter are checked on the rows the index
+	// scan returns, so without an iterative scan fewer than k rows can pass.
+	// Iterative scans keep scanning the index until k rows pass (pgvector 0.8+).
+	if opts.IterativeScan != "" && opts.IterativeScan != IterativeScanOff {
+		if _, err := tx.ExecContext(ctx, "SET LOCAL hnsw.iterative_scan = "+opts.IterativeScan); err != nil {
+			return fmt.Errorf("failed to set hnsw.iterative_scan: %w", err)
+		}
+		// IVFFlat only supports relaxed ordering; the query re-sorts the rows
+		if _, err := tx.ExecContext(ctx, "SET LOCAL ivfflat.iterative_scan = "+IterativeScanRelaxed); err != nil {
+			return fmt.Errorf("failed to set ivfflat.iterative_scan: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (vs *VectorStore) IndexTool(ctx context.Context, tool models.Tool) error {
-	// Get embedding for tool description
-	embedding, err := vs.getEmbedding(ctx, tool.Description)
+	return vs.IndexTools(ctx, []models.Tool{tool})
+}
+
+// CreateTool inserts a new tool, failing with ErrToolExists if the ID is taken.
+// The check and the insert are a single statement, so concurrent creates of
+// the same ID cannot both succeed.
+func (vs *VectorStore) CreateTool(ctx context.Context, tool models.Tool) error {
+	reducer, err := vs.currentReducer(ctx)
+	if err != nil {
+		return err
+	}
+	documents, fields, err := vs.embedTools(ctx, []models.Tool{tool}, reducer)
 	if err != nil {
 		return err
 	}
 
-	// Convert embedding to pgvector format
-	vec := pgvector.NewVector(embedding)
+	tx, err := vs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	// Convert input_schema and output_schema to JSONB
-	inputSchemaJSON, err := json.Marshal(tool.InputSchema)
+	if err := insertTool(ctx, tx, tool, documents[0], reducer.Version()); err != nil {
+		return err
+	}
+	if vs.multiVector {
+		if err := replaceToolVectors(ctx, tx, tool.ID, fields[0], reducer.Version()); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tool: %w", err)
+	}
+	return nil
+}
+
+// IndexTools indexes many tools, embedding their documents (and field texts
+// when multi-vector indexing is on) in batches and writing all rows in a
+// single transaction
+func (vs *VectorStore) IndexTools(ctx context.Context, tools []models.Tool) error {
+	reducer, err := vs.currentReducer(ctx)
+	if err != nil {
+		return err
+	}
+	documents, fields, err := vs.embedTools(ctx, tools, reducer)
 	if err != nil {
-		return fmt.Errorf("failed to marshal input schema: %w", err)
+		return err
 	}
 
-	outputSchemaJSON, err := json.Marshal(tool.OutputSchema)
+	tx, err := vs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := vs.writeTools(ctx, tx, tools, documents, fields, reducer.Version()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tools: %w", err)
+	}
+	return nil
+}
+
+// embedTools embeds each tool's document, and its field texts when
+// multi-vector indexing is on, reducing them with reducer
+func (vs *VectorStore) embedTools(ctx context.Context, tools []models.Tool, reducer Reducer) ([][]float32, []map[string][]float32, error) {
+	inputs, err := vs.vectorInputs(tools)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	texts := make([]string, len(inputs))
+	for i, input := range inputs {
+		texts[i] = input.text
+	}
+	embeddings, err := vs.embedWith(ctx, reducer, texts)
 	if err != nil {
-		return fmt.Errorf("failed to marshal output schema: %w", err)
+		return nil, nil, err
+	}
+
+	// Group embeddings by tool
+	documents := make([][]float32, len(tools))
+	fields := make([]map[string][]float32, len(tools))
+	for i, input := range inputs {
+		if input.field == FieldDocument {
+			documents[input.tool] = embeddings[i]
+		}
+		if vs.multiVector {
+			if fields[input.tool] == nil {
+				fields[input.tool] = make(map[string][]float32)
+			}
+			fields[input.tool][input.field] = embeddings[i]
+		}
+	}
+	return documents, fields, nil
+}
+
+// writeTools upserts tools with the vectors from embedTools
+func (vs *VectorStore) writeTools(ctx context.Context, tx *sql.Tx, tools []models.Tool, documents [][]float32, fields []map[string][]float32, reducerVersion string) error {
+	for i, tool := range tools {
+		if err := upsertTool(ctx, tx, tool, documents[i], reducerVersion); err != nil {
+			return fmt.Errorf("tool %s: %w", tool.ID, err)
+		}
+		if vs.multiVector {
+			if err := replaceToolVectors(ctx, tx, tool.ID, fields[i], reducerVersion); err != nil {
+				return fmt.Errorf("tool %s: %w", tool.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// vectorInput is one text to embed for a tool
+type vectorInput struct {
+	tool  int // index into the tools slice
+	field string
+	text  string
+}
+
+// vectorInputs lists the texts to embed for each tool: the rendered document,
+// plus one text per field when multi-vector indexing is on
+func (vs *VectorStore) vectorInputs(tools []models.Tool) ([]vectorInput, error) {
+	var inputs []vectorInput
+	for i, tool := range tools {
+		document, err := vs.renderer.Render(tool)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, vectorInput{tool: i, field: FieldDocument, text: document})
+
+		if !vs.multiVector {
+			continue
+		}
+		fields := vs.renderer.Fields(tool)
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			inputs = append(inputs, vectorInput{tool: i, field: name, text: fields[name]})
+		}
 	}
+	return inputs, nil
+}
+
+// replaceToolVectors replaces the per-field vectors stored for a tool
+func replaceToolVectors(ctx context.Context, db execer, toolID string, fields map[string][]float32, reducerVersion string) error {
+	if _, err := db.ExecContext(ctx, `DELETE FROM tool_vectors WHERE tool_id = $1`, toolID); err != nil {
+		return fmt.Errorf("failed to clear field vectors: %w", err)
+	}
+
+	for field, embedding := range fields {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO tool_vectors (tool_id, field, embedding, reducer_version)
+			VALUES ($1, $2, $3, $4)
+		`, toolID, field, pgvector.NewVector(embedding), reducerVersion)
+		if err != nil {
+			return fmt.Errorf("failed to store %s vector: %w", field, err)
+		}
+	}
+	return nil
+}
 
-	// Insert or update tool with embedding
-	_, err = vs.db.ExecContext(ctx, `
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertToolSQL inserts a tool row; callers append the ON CONFLICT clause
+const insertToolSQL = `
         INSERT INTO tools (
             id, name, description, category, tags,
             input_schema, output_schema, version,
-            created_at, updated_at, embedding
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+            created_at, updated_at, embedding, version_key, reducer_version
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+// upsertTool inserts or updates a tool row with its embedding
+func upsertTool(ctx context.Context, db execer, tool models.Tool, embedding []float32, reducerVersion string) error {
+	args, err := toolArgs(tool, embedding, reducerVersion)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, insertToolSQL+`
         ON CONFLICT (id) DO UPDATE SET
             name = $2,
             description = $3,
@@ -157,13 +633,195 @@ func (vs *VectorStore) IndexTool(ctx context.Context, tool models.Tool) error {
             output_schema = $7,
             version = $8,
             updated_at = $10,
-            embedding = $11
-    `, tool.ID, tool.Name, tool.Description, tool.Category, pq.Array(tool.Tags),
-		inputSchemaJSON, outputSchemaJSON, tool.Version,
-		tool.CreatedAt, tool.UpdatedAt, vec)
+            embedding = $11,
+            version_key = $12,
+            reducer_version = $13
+    `, args...)
 	if err != nil {
 		return fmt.Errorf("failed to index tool: %w", err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// insertTool inserts a new tool row with its embedding, returning
+// ErrToolExists if the ID is taken
+func insertTool(ctx context.Context, db execer, tool models.Tool, embedding []float32, reducerVersion string) error {
+	args, err := toolArgs(tool, embedding, reducerVersion)
+	if err != nil {
+		return err
+	}
+
+	res, err := db.ExecContext(ctx, insertToolSQL+`
+        ON CONFLICT (id) DO NOTHING
+    `, args...)
+	if err != nil {
+		return fmt.Errorf("failed to insert tool: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to insert tool: %w", err)
+	}
+	if affected == 0 {
+		return ErrToolExists
+	}
+
+	return nil
+}
+
+// toolArgs returns the bind arguments for insertToolSQL
+func toolArgs(tool models.Tool, embedding []float32, reducerVersion string) ([]interface{}, error) {
+	// Convert input_schema and output_schema to JSONB
+	inputSchemaJSON, err := json.Marshal(tool.InputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input schema: %w", err)
+	}
+
+	outputSchemaJSON, err := json.Marshal(tool.OutputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output schema: %w", err)
+	}
+
+	return []interface{}{
+		tool.ID, tool.Name, tool.Description, tool.Category, pq.Array(tool.Tags),
+		inputSchemaJSON, outputSchemaJSON, tool.Version,
+		tool.CreatedAt, tool.UpdatedAt, pgvector.NewVector(embedding), filter.VersionKey(tool.Version), reducerVersion,
+	}, nil
+}
+
+// GetTool returns the tool with the given ID
+func (vs *VectorStore) GetTool(ctx context.Context, id string) (*models.Tool, error) {
+	row := vs.db.QueryRowContext(ctx, `
+		SELECT `+toolColumns+`
+		FROM tools t
+		WHERE t.id = $1
+	`, id)
+
+	tool, err := scanTool(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrToolNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tool, nil
+}
+
+// GetTools returns the tools with the given IDs, keyed by ID. IDs with no tool
+// are left out.
+func (vs *VectorStore) GetTools(ctx context.Context, ids []string) (map[string]models.Tool, error) {
+	rows, err := vs.db.QueryContext(ctx, `
+		SELECT `+toolColumns+`
+		FROM tools t
+		WHERE t.id = ANY($1::text[])
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tools: %w", err)
+	}
+	defer rows.Close()
+
+	tools := make(map[string]models.Tool, len(ids))
+	for rows.Next() {
+		tool, err := scanTool(rows)
+		if err != nil {
+			return nil, err
+		}
+		tools[tool.ID] = *tool
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return tools, nil
+}
+
+// ListTools returns a page of tools ordered by ID, optionally restricted to a category
+func (vs *VectorStore) ListTools(ctx context.Context, category string, limit, offset int) ([]models.Tool, int, error) {
+	var total int
+	err := vs.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM tools t
+		WHERE ($1 = '' OR t.category = $1)
+	`, category).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count tools: %w", err)
+	}
+
+	rows, err := vs.db.QueryContext(ctx, `
+		SELECT `+toolColumns+`
+		FROM tools t
+		WHERE ($1 = '' OR t.category = $1)
+		ORDER BY t.id ASC
+		LIMIT $2 OFFSET $3
+	`, category, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tools: %w", err)
+	}
+	defer rows.Close()
+
+	tools := make([]models.Tool, 0, limit)
+	for rows.Next() {
+		tool, err := scanTool(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		tools = append(tools, *tool)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return tools, total, nil
+}
+
+// DeleteTool removes the tool with the given ID
+func (vs *VectorStore) DeleteTool(ctx context.Context, id string) error {
+	res, err := vs.db.ExecContext(ctx, `DELETE FROM tools WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tool: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete tool: %w", err)
+	}
+	if affected == 0 {
+		return ErrToolNotFound
+	}
+
+	return nil
+}
+
+// scanTool scans a row selected with toolColumns, followed by any extra destinations
+func scanTool(row rowScanner, extra ...interface{}) (*models.Tool, error) {
+	var tool models.Tool
+	var category, version sql.NullString
+	var inputSchemaJSON, outputSchemaJSON []byte
+
+	dest := []interface{}{
+		&tool.ID, &tool.Name, &tool.Description, &category, pq.Array(&tool.Tags),
+		&inputSchemaJSON, &outputSchemaJSON, &version,
+		&tool.CreatedAt, &tool.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+	tool.Category = category.String
+	tool.Version = version.String
+
+	// Unmarshal JSON schemas
+	if len(inputSchemaJSON) > 0 {
+		if err := json.Unmarshal(inputSchemaJSON, &tool.InputSchema); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal input schema: %w", err)
+		}
+	}
+	if len(outputSchemaJSON) > 0 {
+		if err := json.Unmarshal(outputSchemaJSON, &tool.OutputSchema); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal output schema: %w", err)
+		}
+	}
+
+	return &tool, nil
+}
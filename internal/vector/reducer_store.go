@@ -0,0 +1,123 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ErrNoReducer is returned when no fitted reducer has been saved for a model
+var ErrNoReducer = errors.New("no fitted dimensionality reducer")
+
+// SavePCAReducer persists a fitted reducer for model without activating it.
+// ReindexEmbeddings activates it along with the vectors it produced.
+func SavePCAReducer(ctx context.Context, db *sql.DB, model string, r *PCAReducer) error {
+	in, out := r.Dims()
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO dimension_reducers (
+			version, method, model, input_dim, output_dim, mean, components, active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, FALSE)
+	`, r.version, ReductionPCA, model, in, out,
+		encodeFloats(r.mean), encodeFloats(r.components.RawMatrix().Data))
+	if err != nil {
+		return fmt.Errorf("failed to save reducer: %w", err)
+	}
+	return nil
+}
+
+// activatePCAReducer makes a saved reducer the active one for model
+func activatePCAReducer(ctx context.Context, db execer, model, version string) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE dimension_reducers SET active = FALSE
+		WHERE model = $1 AND method = $2 AND active
+	`, model, ReductionPCA)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate previous reducers: %w", err)
+	}
+
+	res, err := db.ExecContext(ctx, `
+		UPDATE dimension_reducers SET active = TRUE
+		WHERE model = $1 AND method = $2 AND version = $3
+	`, model, ReductionPCA, version)
+	if err != nil {
+		return fmt.Errorf("failed to activate reducer: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("reducer %s: %w", version, ErrNoReducer)
+	}
+	return nil
+}
+
+// ActivePCAVersion returns the version of the active fitted reducer for model
+func ActivePCAVersion(ctx context.Context, db *sql.DB, model string) (string, error) {
+	var version string
+	err := db.QueryRowContext(ctx, `
+		SELECT version FROM dimension_reducers
+		WHERE model = $1 AND method = $2 AND active
+	`, model, ReductionPCA).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNoReducer
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check active reducer: %w", err)
+	}
+	return version, nil
+}
+
+// LoadPCAReducer loads the active fitted reducer for model
+func LoadPCAReducer(ctx context.Context, db *sql.DB, model string) (*PCAReducer, error) {
+	var version string
+	var in, out int
+	var meanData, componentData []byte
+	err := db.QueryRowContext(ctx, `
+		SELECT version, input_dim, output_dim, mean, components
+		FROM dimension_reducers
+		WHERE model = $1 AND method = $2 AND active
+	`, model, ReductionPCA).Scan(&version, &in, &out, &meanData, &componentData)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoReducer
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reducer: %w", err)
+	}
+
+	mean, err := decodeFloats(meanData, in)
+	if err != nil {
+		return nil, fmt.Errorf("reducer %s mean: %w", version, err)
+	}
+	components, err := decodeFloats(componentData, in*out)
+	if err != nil {
+		return nil, fmt.Errorf("reducer %s components: %w", version, err)
+	}
+
+	return &PCAReducer{
+		version:    version,
+		mean:       mean,
+		components: mat.NewDense(in, out, components),
+	}, nil
+}
+
+// encodeFloats serializes floats as little-endian float64 values
+func encodeFloats(values []float64) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, values)
+	return buf.Bytes()
+}
+
+// decodeFloats reverses encodeFloats, checking the expected length
+func decodeFloats(data []byte, n int) ([]float64, error) {
+	if len(data) != n*8 {
+		return nil, fmt.Errorf("expected %d values, got %d bytes", n, len(data))
+	}
+	values := make([]float64, n)
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,205 @@
+package vector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"smartsearch/pkg/models"
+	"time"
+
+	pgvector "github.com/pgvector/pgvector-go"
+)
+
+// AllTools loads every tool in the store, ordered by ID
+func (vs *VectorStore) AllTools(ctx context.Context) ([]models.Tool, error) {
+	rows, err := vs.db.QueryContext(ctx, `
+		SELECT `+toolColumns+`
+		FROM tools t
+		ORDER BY t.id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tools: %w", err)
+	}
+	defer rows.Close()
+
+	var tools []models.Tool
+	for rows.Next() {
+		tool, err := scanTool(rows)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, *tool)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return tools, nil
+}
+
+// ToolsUpdatedSince loads the tools created or updated at or after since,
+// ordered by ID
+func (vs *VectorStore) ToolsUpdatedSince(ctx context.Context, since time.Time) ([]models.Tool, error) {
+	rows, err := vs.db.QueryContext(ctx, `
+		SELECT `+toolColumns+`
+		FROM tools t
+		WHERE t.updated_at >= $1
+		ORDER BY t.id ASC
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load updated tools: %w", err)
+	}
+	defer rows.Close()
+
+	var tools []models.Tool
+	for rows.Next() {
+		tool, err := scanTool(rows)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, *tool)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return tools, nil
+}
+
+// ToolIDs lists the ID of every tool in the store
+func (vs *VectorStore) ToolIDs(ctx context.Context) ([]string, error) {
+	rows, err := vs.db.QueryContext(ctx, `SELECT id FROM tools`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tool IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan tool ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return ids, nil
+}
+
+// FitPCA fits a PCA reducer over the raw embeddings of every tool and saves it
+// for the embedding model. It only takes effect once ReindexEmbeddings has
+// re-embedded the tools with it.
+func (vs *VectorStore) FitPCA(ctx context.Context) (*PCAReducer, error) {
+	tools, err := vs.AllTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fit over every text that will be embedded, including field texts
+	inputs, err := vs.vectorInputs(tools)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(inputs))
+	for i, input := range inputs {
+		texts[i] = input.text
+	}
+	raw, err := vs.embedder.GetEmbeddings(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embeddings: %w", err)
+	}
+
+	reducer, err := FitPCA(raw, vs.targetDim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fit PCA: %w", err)
+	}
+	if err := SavePCAReducer(ctx, vs.db, vs.model, reducer); err != nil {
+		return nil, err
+	}
+
+	vs.logger.Info("fitted reducer", "version", reducer.Version(), "texts", len(texts), "tools", len(tools))
+	return reducer, nil
+}
+
+// ReindexEmbeddings re-embeds every tool with the current document template so
+// stored vectors and queries share one vector space. A non-nil pca reducer is
+// used instead of the active one and activated in the same transaction as the
+// new vectors, so a failure leaves the previous reducer and vectors in place.
+// The embedding columns are resized to the configured dimensionality first.
+// Only the vector columns are written, so updated_at is left alone.
+// It returns the number of tools updated.
+func (vs *VectorStore) ReindexEmbeddings(ctx context.Context, pca *PCAReducer) (int, error) {
+	var reducer Reducer = pca
+	if pca == nil {
+		var err error
+		if reducer, err = vs.getReducer(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	tools, err := vs.AllTools(ctx)
+	if err != nil {
+		return 0, err
+	}
+	documents, fields, err := vs.embedTools(ctx, tools, reducer)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := vs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	resized, err := resizeEmbeddings(ctx, tx, vs.targetDim, true)
+	if err != nil {
+		return 0, err
+	}
+	if err := vs.writeEmbeddings(ctx, tx, tools, documents, fields, reducer.Version()); err != nil {
+		return 0, err
+	}
+	// Built after the writes so ivfflat lists are trained on the new vectors
+	if err := createEmbeddingIndexes(ctx, tx); err != nil {
+		return 0, err
+	}
+	if pca != nil {
+		if err := activatePCAReducer(ctx, tx, vs.model, pca.Version()); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit embeddings: %w", err)
+	}
+	if pca != nil {
+		vs.setReducer(pca)
+	}
+	if resized {
+		vs.logger.Info("resized embedding columns", "dimensions", vs.targetDim)
+	}
+	return len(tools), nil
+}
+
+// writeEmbeddings stores new vectors for tools without touching their other
+// columns. Tools deleted since they were loaded are skipped.
+func (vs *VectorStore) writeEmbeddings(ctx context.Context, tx *sql.Tx, tools []models.Tool, documents [][]float32, fields []map[string][]float32, reducerVersion string) error {
+	for i, tool := range tools {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE tools SET embedding = $2, reducer_version = $3
+			WHERE id = $1
+		`, tool.ID, pgvector.NewVector(documents[i]), reducerVersion)
+		if err != nil {
+			return fmt.Errorf("tool %s: failed to store embedding: %w", tool.ID, err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			continue
+		}
+		if vs.multiVector {
+			if err := replaceToolVectors(ctx, tx, tool.ID, fields[i], reducerVersion); err != nil {
+				return fmt.Errorf("tool %s: %w", tool.ID, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package vector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"smartsearch/pkg/models"
+	"sort"
+	"testing"
+	"time"
+)
+
+// testDB returns a connection to a fresh schema with every migration applied.
+// These tests need PostgreSQL with pgvector and are skipped unless
+// SMARTSEARCH_TEST_DSN is set.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("SMARTSEARCH_TEST_DSN")
+	if dsn == "" {
+		t.Skip("SMARTSEARCH_TEST_DSN not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// search_path is per connection, so keep to one
+	db.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("smartsearch_test_%d", time.Now().UnixNano())
+	if _, err := db.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		db.Close()
+	})
+	if _, err := db.Exec(`SET search_path = ` + schema + `, public`); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(migration)); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(file), err)
+		}
+	}
+	return db
+}
+
+// dbNow returns the database clock, which updated_at is set from
+func dbNow(t *testing.T, db *sql.DB) time.Time {
+	t.Helper()
+	var now time.Time
+	if err := db.QueryRow(`SELECT clock_timestamp()`).Scan(&now); err != nil {
+		t.Fatal(err)
+	}
+	return now
+}
+
+func updatedIDs(t *testing.T, vs *VectorStore, since time.Time) []string {
+	t.Helper()
+	tools, err := vs.ToolsUpdatedSince(context.Background(), since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, tool := range tools {
+		ids = append(ids, tool.ID)
+	}
+	return ids
+}
+
+func TestToolsUpdatedSince(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	vs := &VectorStore{db: db, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resizeEmbeddings(ctx, tx, 3, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	written := time.Now().Add(-time.Hour).UTC().Truncate(time.Microsecond)
+	tools := []models.Tool{
+		{ID: "a", Name: "a", Description: "a", CreatedAt: written, UpdatedAt: written},
+		{ID: "b", Name: "b", Description: "b", CreatedAt: written, UpdatedAt: written},
+	}
+	for _, tool := range tools {
+		if err := upsertTool(ctx, db, tool, []float32{1, 0, 0}, "none"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The cutoff is inclusive
+	if got := updatedIDs(t, vs, written); fmt.Sprint(got) != "[a b]" {
+		t.Errorf("updated since the write = %v, want [a b]", got)
+	}
+	since := dbNow(t, db)
+	if got := updatedIDs(t, vs, since); len(got) != 0 {
+		t.Errorf("updated since now = %v, want none", got)
+	}
+
+	// Re-embedding rewrites only the vectors, which must not count as an
+	// update or every reindex would replay the whole corpus
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vs.writeEmbeddings(ctx, tx, tools, [][]float32{{0, 1, 0}, {0, 0, 1}}, make([]map[string][]float32, 2), "pca-3-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := updatedIDs(t, vs, since); len(got) != 0 {
+		t.Errorf("updated after re-embedding = %v, want none", got)
+	}
+	tool, err := vs.GetTool(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tool.UpdatedAt.Equal(written) {
+		t.Errorf("re-embedding moved updated_at from %v to %v", written, tool.UpdatedAt)
+	}
+
+	// Changing the tool bumps updated_at, even when the writer passes the old value
+	changed := tools[1]
+	changed.Description = "changed"
+	if err := upsertTool(ctx, db, changed, []float32{0, 0, 1}, "pca-3-test"); err != nil {
+		t.Fatal(err)
+	}
+	if got := updatedIDs(t, vs, since); fmt.Sprint(got) != "[b]" {
+		t.Errorf("updated after editing b = %v, want [b]", got)
+	}
+}
@@ -0,0 +1,18 @@
+-- Sortable version key used by version range filters (see filter.VersionKey)
+ALTER TABLE tools ADD COLUMN IF NOT EXISTS version_key TEXT;
+
+-- Backfill existing rows using the same normalization as filter.VersionKey
+UPDATE tools SET version_key = (
+    SELECT string_agg(
+        lpad(least(coalesce(nullif(substring(split_part(v, '.', n) from '^[0-9]+'), ''), '0')::numeric, 999999)::text, 6, '0'),
+        '.' ORDER BY n
+    )
+    FROM (SELECT regexp_replace(trim(coalesce(version, '')), '^v', '') AS v) s,
+         generate_series(1, 3) AS n
+)
+WHERE version_key IS NULL;
+
+-- Indexes for filtered search
+CREATE INDEX IF NOT EXISTS idx_tools_version_key ON tools(version_key);
+CREATE INDEX IF NOT EXISTS idx_tools_created_at ON tools(created_at);
+CREATE INDEX IF NOT EXISTS idx_tools_updated_at ON tools(updated_at);
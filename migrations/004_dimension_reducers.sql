@@ -0,0 +1,22 @@
+-- Fitted dimensionality reducers (see vector.PCAReducer)
+CREATE TABLE IF NOT EXISTS dimension_reducers (
+    version TEXT PRIMARY KEY,
+    method TEXT NOT NULL,
+    model TEXT NOT NULL,
+    input_dim INTEGER NOT NULL,
+    output_dim INTEGER NOT NULL,
+    mean BYTEA NOT NULL,        -- little-endian float64, input_dim values
+    components BYTEA NOT NULL,  -- little-endian float64, input_dim x output_dim row-major
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- At most one active reducer per model and method
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dimension_reducers_active
+    ON dimension_reducers(model, method) WHERE active;
+
+-- Reducer version each tool vector was produced with. Existing vectors were
+-- stored without reduction.
+ALTER TABLE tools ADD COLUMN IF NOT EXISTS reducer_version TEXT;
+UPDATE tools SET reducer_version = 'none' WHERE reducer_version IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tools_reducer_version ON tools(reducer_version);
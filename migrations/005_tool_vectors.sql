@@ -0,0 +1,15 @@
+-- Per-field tool vectors for multi-vector indexing (embedding.multi_vector).
+-- Each tool has one row for its rendered document, name, description and each
+-- input:/output: schema parameter.
+-- The embedding column matches tools.embedding from 001_initial_schema.sql.
+-- SQL migrations cannot read config, so cmd/migrate and cmd/fitreducer resize
+-- both columns to ollama.options.dimensionality with resize_embeddings (007).
+CREATE TABLE IF NOT EXISTS tool_vectors (
+    tool_id TEXT NOT NULL REFERENCES tools(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    embedding vector(768) NOT NULL,
+    reducer_version TEXT NOT NULL,
+    PRIMARY KEY (tool_id, field)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tool_vectors_reducer_version ON tool_vectors(reducer_version);
@@ -0,0 +1,68 @@
+-- Flattened input/output schema parameters for the inputs/outputs filters.
+-- These functions mirror schema.Flatten so both search backends see the same
+-- parameter names and types; keep them in sync.
+CREATE OR REPLACE FUNCTION schema_type(s JSONB)
+RETURNS TEXT AS $$
+DECLARE
+    item_type TEXT;
+BEGIN
+    CASE jsonb_typeof(s->'type')
+    WHEN 'string' THEN
+        IF s->>'type' = 'array' AND jsonb_typeof(s->'items') = 'object' THEN
+            item_type := schema_type(s->'items');
+            IF item_type <> '' THEN
+                RETURN 'array<' || item_type || '>';
+            END IF;
+        END IF;
+        RETURN s->>'type';
+    WHEN 'array' THEN
+        RETURN coalesce((
+            SELECT string_agg(t #>> '{}', '|' ORDER BY ord)
+            FROM jsonb_array_elements(s->'type') WITH ORDINALITY AS e(t, ord)
+            WHERE jsonb_typeof(t) = 'string'
+        ), '');
+    ELSE
+        RETURN '';
+    END CASE;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION schema_parameters(s JSONB, direction TEXT, prefix TEXT DEFAULT '', depth INT DEFAULT 0)
+RETURNS JSONB AS $$
+DECLARE
+    params JSONB := '[]';
+    prop_name TEXT;
+    prop JSONB;
+    path TEXT;
+BEGIN
+    IF s IS NULL OR depth > 8 OR jsonb_typeof(s->'properties') IS DISTINCT FROM 'object' THEN
+        RETURN params;
+    END IF;
+
+    FOR prop_name, prop IN SELECT key, value FROM jsonb_each(s->'properties') ORDER BY key LOOP
+        CONTINUE WHEN jsonb_typeof(prop) <> 'object';
+
+        path := CASE WHEN prefix = '' THEN prop_name ELSE prefix || '.' || prop_name END;
+        params := params || jsonb_build_array(jsonb_build_object(
+            'direction', direction,
+            'name', path,
+            'type', schema_type(prop),
+            'required', coalesce(jsonb_typeof(s->'required') = 'array' AND s->'required' ? prop_name, false)
+        ));
+
+        params := params || schema_parameters(prop, direction, path, depth + 1);
+        IF jsonb_typeof(prop->'items') = 'object' THEN
+            params := params || schema_parameters(prop->'items', direction, path || '[]', depth + 1);
+        END IF;
+    END LOOP;
+
+    RETURN params;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+ALTER TABLE tools ADD COLUMN IF NOT EXISTS parameters JSONB
+    GENERATED ALWAYS AS (
+        schema_parameters(input_schema, 'input') || schema_parameters(output_schema, 'output')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_tools_parameters ON tools USING GIN(parameters jsonb_path_ops);
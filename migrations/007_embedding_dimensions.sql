@@ -0,0 +1,40 @@
+-- Embedding columns are sized by ollama.options.dimensionality. cmd/migrate
+-- sizes them while they are empty; cmd/fitreducer resizes them in the same
+-- transaction that re-embeds every tool.
+CREATE OR REPLACE FUNCTION resize_embeddings(dims INT, clear_existing BOOLEAN DEFAULT FALSE)
+RETURNS BOOLEAN AS $$
+DECLARE
+    current_dims INT;
+BEGIN
+    -- pgvector stores the dimension as the column's type modifier
+    SELECT atttypmod INTO current_dims FROM pg_attribute
+    WHERE attrelid = 'tools'::regclass AND attname = 'embedding';
+    IF current_dims = dims THEN
+        RETURN FALSE;
+    END IF;
+
+    IF NOT clear_existing AND (
+        EXISTS (SELECT 1 FROM tools WHERE embedding IS NOT NULL) OR EXISTS (SELECT 1 FROM tool_vectors)
+    ) THEN
+        RAISE EXCEPTION 'stored embeddings have % dimensions, not %; re-embed them with cmd/fitreducer',
+            current_dims, dims;
+    END IF;
+
+    -- ANN indexes are tied to the dimension; create_embedding_indexes rebuilds
+    -- them once the new vectors are written
+    DROP INDEX IF EXISTS idx_tools_embedding;
+    DELETE FROM tool_vectors;
+    EXECUTE format('ALTER TABLE tools ALTER COLUMN embedding TYPE vector(%s) USING NULL', dims);
+    EXECUTE format('ALTER TABLE tool_vectors ALTER COLUMN embedding TYPE vector(%s) USING NULL', dims);
+    UPDATE tools SET reducer_version = NULL;
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION create_embedding_indexes()
+RETURNS VOID AS $$
+BEGIN
+    CREATE INDEX IF NOT EXISTS idx_tools_embedding ON tools
+        USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100);
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,14 @@
+-- ANN index for multi-vector search. HNSW needs no training data, so it can be
+-- built on the empty table and is rebuilt by resize_embeddings' ALTER TYPE.
+CREATE INDEX IF NOT EXISTS idx_tool_vectors_embedding ON tool_vectors
+    USING hnsw (embedding vector_cosine_ops);
+
+CREATE OR REPLACE FUNCTION create_embedding_indexes()
+RETURNS VOID AS $$
+BEGIN
+    CREATE INDEX IF NOT EXISTS idx_tools_embedding ON tools
+        USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100);
+    CREATE INDEX IF NOT EXISTS idx_tool_vectors_embedding ON tool_vectors
+        USING hnsw (embedding vector_cosine_ops);
+END;
+$$ LANGUAGE plpgsql;
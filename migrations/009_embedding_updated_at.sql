@@ -0,0 +1,14 @@
+-- Re-embedding only rewrites the vector columns, which must not count as a
+-- tool change: updated_at drives the updated_at filter and the reindex replay.
+-- Writes that change anything else, or set updated_at themselves, still bump it.
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.updated_at IS DISTINCT FROM OLD.updated_at OR
+       to_jsonb(NEW) - 'embedding' - 'reducer_version' IS DISTINCT FROM
+       to_jsonb(OLD) - 'embedding' - 'reducer_version' THEN
+        NEW.updated_at = CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -1,44 +1,101 @@
 package config
 
-import (
-	"encoding/json"
-	"os"
-)
-
+// Config holds all service settings. Every field can be overridden with an
+// environment variable named after its JSON path, e.g. SMARTSEARCH_POSTGRESQL_DSN.
 type Config struct {
 	PostgreSQL struct {
-		DSN string `json:"dsn"`
+		DSN string `json:"dsn" secret:"true"`
 	} `json:"postgresql"`
 	OpenSearch struct {
 		URL      string `json:"url"`
 		Username string `json:"username"`
-		Password string `json:"password"`
+		Password string `json:"password" secret:"true"`
 		Index    string `json:"index"`
+		// How often the live index mapping is compared with the expected one
+		MappingCheckIntervalMS int `json:"mapping_check_interval_ms"`
+		// Text analysis for tool name and description; changes need a reindex,
+		// which /admin/synonyms/reload runs for synonym edits
+		Analysis struct {
+			Stemmer       string `json:"stemmer"` // OpenSearch stemmer language, or "none"
+			ASCIIFolding  bool   `json:"ascii_folding"`
+			WordDelimiter bool   `json:"word_delimiter"` // split identifiers like gmail_send_email
+			SynonymsFile  string `json:"synonyms_file"`  // Solr-format rules, applied at query time
+		} `json:"analysis"`
 	} `json:"opensearch"`
 	Ollama struct {
 		URL        string `json:"url"`
 		EmbedModel string `json:"embed_model"`
 		ChatModel  string `json:"chat_model"`
 		Options    struct {
-			Dimensionality int `json:"dimensionality"`
+			Dimensionality   int    `json:"dimensionality"`
+			Reduction        string `json:"reduction"`
+			EmbedBatchSize   int    `json:"embed_batch_size"`
+			EmbedBatchTokens int    `json:"embed_batch_tokens"`
 		} `json:"options"`
 	} `json:"ollama"`
+	Embedding struct {
+		Template    string `json:"template"`
+		MultiVector bool   `json:"multi_vector"`
+	} `json:"embedding"`
+	EmbeddingCache struct {
+		Enabled    bool `json:"enabled"`
+		Size       int  `json:"size"`
+		Persistent bool `json:"persistent"`
+	} `json:"embedding_cache"`
+	Search struct {
+		DefaultTopK         int `json:"default_top_k"`
+		MaxTopK             int `json:"max_top_k"`
+		CandidateMultiplier int `json:"candidate_multiplier"`
+		EfSearch            int `json:"ef_search"`
+		IVFFlatProbes       int `json:"ivfflat_probes"`
+		// pgvector iterative index scan mode for vector queries
+		IterativeScan string `json:"iterative_scan"`
+		// Zero uses the fusion strategy's own default
+		DefaultMinScore float64 `json:"default_min_score"`
+		// Deepest result position reachable with offset and cursors
+		MaxResultWindow int `json:"max_result_window"`
+		// HMAC key for pagination cursors; empty uses a random key per process
+		CursorSecret string `json:"cursor_secret" secret:"true"`
+		// Per-leg deadlines; a leg that errors or times out fails the request
+		// in "strict" mode, or is left out in "best_effort" mode
+		VectorTimeoutMS  int    `json:"vector_timeout_ms"`
+		KeywordTimeoutMS int    `json:"keyword_timeout_ms"`
+		FailureMode      string `json:"failure_mode"`
+	} `json:"search"`
+	Fusion struct {
+		Strategy      string  `json:"strategy"`
+		VectorWeight  float64 `json:"vector_weight"`
+		KeywordWeight float64 `json:"keyword_weight"`
+		RRFK          int     `json:"rrf_k"`
+	} `json:"fusion"`
+	QueryUnderstanding struct {
+		Enabled       bool `json:"enabled"`
+		TimeoutMS     int  `json:"timeout_ms"`
+		MaxSubQueries int  `json:"max_sub_queries"`
+	} `json:"query_understanding"`
+	Rerank struct {
+		Enabled   bool   `json:"enabled"`
+		Model     string `json:"model"`
+		TopN      int    `json:"top_n"`
+		TimeoutMS int    `json:"timeout_ms"`
+		Fallback  bool   `json:"fallback"`
+	} `json:"rerank"`
+	Logging struct {
+		Level   string `json:"level"`
+		Format  string `json:"format"`
+		Queries string `json:"queries"`
+	} `json:"logging"`
+	Tracing struct {
+		Enabled     bool    `json:"enabled"`
+		Endpoint    string  `json:"endpoint"`
+		Insecure    bool    `json:"insecure"`
+		ServiceName string  `json:"service_name"`
+		SampleRatio float64 `json:"sample_ratio"`
+	} `json:"tracing"`
 	Server struct {
 		Host string `json:"host"`
 		Port int    `json:"port"`
+		// Bearer token for /admin endpoints; empty disables them
+		AdminToken string `json:"admin_token" secret:"true"`
 	} `json:"server"`
 }
-
-func LoadConfig(path string) (*Config, error) {
-	file, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	var config Config
-	if err := json.Unmarshal(file, &config); err != nil {
-		return nil, err
-	}
-
-	return &config, nil
-} 
\ No newline at end of file
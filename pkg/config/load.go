@@ -0,0 +1,224 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes every environment variable override
+const EnvPrefix = "SMARTSEARCH_"
+
+// secretScheme marks a string value that should be read from a file
+const secretScheme = "file://"
+
+// defaultPath is the config file used when no path is given and it exists
+const defaultPath = "config.json"
+
+// Default returns the built-in settings that the config file and environment
+// are layered on top of
+func Default() *Config {
+	var cfg Config
+	cfg.OpenSearch.Index = "tools"
+	cfg.OpenSearch.MappingCheckIntervalMS = 300000
+	cfg.OpenSearch.Analysis.Stemmer = "english"
+	cfg.OpenSearch.Analysis.ASCIIFolding = true
+	cfg.OpenSearch.Analysis.WordDelimiter = true
+	cfg.Ollama.URL = "http://localhost:11434/v1"
+	cfg.Ollama.EmbedModel = "nomic-embed-text"
+	cfg.Ollama.ChatModel = "qwen3:4b"
+	cfg.Ollama.Options.Dimensionality = 768
+	cfg.Ollama.Options.Reduction = "none"
+	cfg.Ollama.Options.EmbedBatchSize = 64
+	cfg.Ollama.Options.EmbedBatchTokens = 8192
+	cfg.EmbeddingCache.Size = 10000
+	cfg.Search.DefaultTopK = 5
+	cfg.Search.MaxTopK = 100
+	cfg.Search.CandidateMultiplier = 4
+	cfg.Search.IterativeScan = "relaxed_order"
+	cfg.Search.MaxResultWindow = 1000
+	cfg.Search.VectorTimeoutMS = 5000
+	cfg.Search.KeywordTimeoutMS = 3000
+	cfg.Search.FailureMode = "best_effort"
+	cfg.Fusion.Strategy = "rrf"
+	cfg.Fusion.VectorWeight = 0.7
+	cfg.Fusion.KeywordWeight = 0.3
+	cfg.Fusion.RRFK = 60
+	cfg.QueryUnderstanding.TimeoutMS = 10000
+	cfg.QueryUnderstanding.MaxSubQueries = 3
+	cfg.Rerank.TopN = 10
+	cfg.Rerank.TimeoutMS = 15000
+	cfg.Logging.Level = "info"
+	cfg.Logging.Format = "json"
+	cfg.Logging.Queries = "hash"
+	cfg.Server.Host = "localhost"
+	cfg.Server.Port = 8080
+	return &cfg
+}
+
+// DefaultPath returns the config file to load when --config is not given:
+// SMARTSEARCH_CONFIG if set, otherwise config.json when it exists. An empty
+// result means settings come from defaults and the environment only.
+func DefaultPath() string {
+	if path := os.Getenv(EnvPrefix + "CONFIG"); path != "" {
+		return path
+	}
+	if _, err := os.Stat(defaultPath); err == nil {
+		return defaultPath
+	}
+	return ""
+}
+
+// LoadConfig builds the configuration in layers: defaults, then the file at
+// path (JSON or YAML, skipped when path is empty), then SMARTSEARCH_*
+// environment variables. String values of the form file:///path are replaced
+// with the contents of that file. The result is validated before returning.
+func LoadConfig(path string) (*Config, error) {
+	config := Default()
+
+	if path != "" {
+		if err := loadFile(path, config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix); err != nil {
+		return nil, err
+	}
+
+	if err := resolveSecrets(reflect.ValueOf(config).Elem(), ""); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// loadFile decodes a JSON or YAML config file over config
+func loadFile(path string, config *Config) error {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// Round-trip through JSON so the json tags are the only field names
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(file, &raw); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if file, err = json.Marshal(raw); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+
+	if err := json.Unmarshal(file, config); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides each field from the environment variable named after its
+// JSON path, e.g. Ollama.Options.Dimensionality from
+// SMARTSEARCH_OLLAMA_OPTIONS_DIMENSIONALITY
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := prefix + strings.ToUpper(jsonName(t.Field(i)))
+		field := v.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setField parses value into a string, integer, float or bool field
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Kind())
+	}
+	return nil
+}
+
+// resolveSecrets replaces file:// string values with the referenced file's
+// contents, minus trailing newlines
+func resolveSecrets(v reflect.Value, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := jsonName(t.Field(i))
+		if path != "" {
+			name = path + "." + name
+		}
+		field := v.Field(i)
+
+		switch field.Kind() {
+		case reflect.Struct:
+			if err := resolveSecrets(field, name); err != nil {
+				return err
+			}
+		case reflect.String:
+			ref, ok := strings.CutPrefix(field.String(), secretScheme)
+			if !ok {
+				continue
+			}
+			secret, err := os.ReadFile(ref)
+			if err != nil {
+				return fmt.Errorf("failed to read secret for %s: %w", name, err)
+			}
+			field.SetString(strings.TrimRight(string(secret), "\r\n"))
+		}
+	}
+	return nil
+}
+
+// jsonName returns the JSON key of a struct field
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
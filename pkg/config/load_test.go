@@ -0,0 +1,167 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to name in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// required sets the settings that have no default
+func required(t *testing.T) {
+	t.Setenv("SMARTSEARCH_POSTGRESQL_DSN", "postgres://env")
+	t.Setenv("SMARTSEARCH_OPENSEARCH_URL", "http://env:9200")
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	secret := writeFile(t, "password", "s3cret\n")
+
+	tests := []struct {
+		name  string
+		file  string // file name and content; empty loads no file
+		body  string
+		env   map[string]string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "defaults only",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Search.DefaultTopK != 5 || cfg.Fusion.Strategy != "rrf" || cfg.Server.Port != 8080 {
+					t.Errorf("defaults not applied: %+v", cfg.Search)
+				}
+			},
+		},
+		{
+			name: "json file over defaults",
+			file: "config.json",
+			body: `{"search": {"default_top_k": 8}, "fusion": {"strategy": "zscore"}}`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Search.DefaultTopK != 8 || cfg.Fusion.Strategy != "zscore" {
+					t.Errorf("file not applied: top_k %d strategy %s", cfg.Search.DefaultTopK, cfg.Fusion.Strategy)
+				}
+				// Settings the file leaves out keep their defaults
+				if cfg.Search.MaxTopK != 100 || cfg.Fusion.RRFK != 60 {
+					t.Errorf("defaults lost: max_top_k %d rrf_k %d", cfg.Search.MaxTopK, cfg.Fusion.RRFK)
+				}
+			},
+		},
+		{
+			name: "yaml file",
+			file: "config.yaml",
+			body: "search:\n  default_top_k: 9\nembedding:\n  multi_vector: true\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Search.DefaultTopK != 9 || !cfg.Embedding.MultiVector {
+					t.Errorf("yaml not applied: %+v %+v", cfg.Search, cfg.Embedding)
+				}
+			},
+		},
+		{
+			name: "environment over file",
+			file: "config.json",
+			body: `{"search": {"default_top_k": 8}, "postgresql": {"dsn": "postgres://file"}}`,
+			env: map[string]string{
+				"SMARTSEARCH_SEARCH_DEFAULT_TOP_K":              "3",
+				"SMARTSEARCH_FUSION_VECTOR_WEIGHT":              "0.5",
+				"SMARTSEARCH_RERANK_ENABLED":                    "true",
+				"SMARTSEARCH_OLLAMA_OPTIONS_DIMENSIONALITY":     "256",
+				"SMARTSEARCH_OPENSEARCH_ANALYSIS_ASCII_FOLDING": "false",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Search.DefaultTopK != 3 || cfg.Fusion.VectorWeight != 0.5 || !cfg.Rerank.Enabled ||
+					cfg.Ollama.Options.Dimensionality != 256 || cfg.OpenSearch.Analysis.ASCIIFolding {
+					t.Errorf("environment not applied: %+v", cfg)
+				}
+				if cfg.PostgreSQL.DSN != "postgres://env" {
+					t.Errorf("dsn = %q, want the environment's", cfg.PostgreSQL.DSN)
+				}
+			},
+		},
+		{
+			name: "secret from file",
+			env:  map[string]string{"SMARTSEARCH_OPENSEARCH_PASSWORD": "file://" + secret},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.OpenSearch.Password != "s3cret" {
+					t.Errorf("password = %q, want the file contents without newline", cfg.OpenSearch.Password)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			required(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeFile(t, tt.file, tt.body)
+			}
+
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		body string
+		env  map[string]string
+		want string
+	}{
+		{name: "bad json", file: "config.json", body: `{"search": `, want: "failed to parse"},
+		{name: "bad yaml", file: "config.yml", body: "search: [", want: "failed to parse"},
+		{name: "bad env int", env: map[string]string{"SMARTSEARCH_SERVER_PORT": "http"}, want: "invalid SMARTSEARCH_SERVER_PORT"},
+		{name: "missing secret file", env: map[string]string{"SMARTSEARCH_OPENSEARCH_PASSWORD": "file:///does/not/exist"}, want: "opensearch.password"},
+		{name: "invalid result", env: map[string]string{"SMARTSEARCH_FUSION_STRATEGY": "bm25"}, want: "fusion.strategy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			required(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeFile(t, tt.file, tt.body)
+			}
+
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
+
+// The config.json shipped with the repository must load as is
+func TestRepositoryConfig(t *testing.T) {
+	cfg, err := LoadConfig("../../config.json")
+	if err != nil {
+		t.Fatalf("config.json: %v", err)
+	}
+	if cfg.Embedding.MultiVector {
+		t.Error("config.json should leave multi_vector off until tool_vectors is filled")
+	}
+}
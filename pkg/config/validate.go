@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError lists every missing or invalid setting
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that required settings are present and values are in range,
+// reporting all problems at once
+func (c *Config) Validate() error {
+	var problems []string
+	require := func(name, value string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, fmt.Sprintf("%s is required (set %s)", name, envName(name)))
+		}
+	}
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	require("postgresql.dsn", c.PostgreSQL.DSN)
+	require("opensearch.url", c.OpenSearch.URL)
+	require("opensearch.index", c.OpenSearch.Index)
+	check(c.OpenSearch.MappingCheckIntervalMS >= 0, "opensearch.mapping_check_interval_ms must not be negative")
+	check(!strings.ContainsAny(c.OpenSearch.Analysis.Stemmer, " \t"), "opensearch.analysis.stemmer must be a single language name")
+	require("ollama.url", c.Ollama.URL)
+	require("ollama.embed_model", c.Ollama.EmbedModel)
+	require("ollama.chat_model", c.Ollama.ChatModel)
+
+	opts := c.Ollama.Options
+	check(opts.Dimensionality > 0, "ollama.options.dimensionality must be positive")
+	check(oneOf(opts.Reduction, "", "none", "truncate", "pca"),
+		"ollama.options.reduction must be one of none, truncate, pca")
+	check(opts.EmbedBatchSize >= 0, "ollama.options.embed_batch_size must not be negative")
+	check(opts.EmbedBatchTokens >= 0, "ollama.options.embed_batch_tokens must not be negative")
+
+	check(!c.EmbeddingCache.Enabled || c.EmbeddingCache.Size >= 0, "embedding_cache.size must not be negative")
+
+	s := c.Search
+	check(s.DefaultTopK >= 0, "search.default_top_k must not be negative")
+	check(s.MaxTopK >= 0, "search.max_top_k must not be negative")
+	check(s.MaxTopK == 0 || s.DefaultTopK <= s.MaxTopK, "search.default_top_k must not exceed search.max_top_k")
+	check(s.CandidateMultiplier >= 0, "search.candidate_multiplier must not be negative")
+	check(s.EfSearch >= 0, "search.ef_search must not be negative")
+	check(s.IVFFlatProbes >= 0, "search.ivfflat_probes must not be negative")
+	check(oneOf(s.IterativeScan, "", "off", "relaxed_order", "strict_order"),
+		"search.iterative_scan must be one of off, relaxed_order, strict_order")
+	check(s.DefaultMinScore >= 0 && s.DefaultMinScore <= 1, "search.default_min_score must be between 0 and 1")
+	check(s.MaxResultWindow >= 0, "search.max_result_window must not be negative")
+	check(s.CursorSecret == "" || len(s.CursorSecret) >= 16, "search.cursor_secret must be at least 16 bytes")
+	check(s.VectorTimeoutMS >= 0, "search.vector_timeout_ms must not be negative")
+	check(s.KeywordTimeoutMS >= 0, "search.keyword_timeout_ms must not be negative")
+	check(oneOf(s.FailureMode, "", "best_effort", "strict"), "search.failure_mode must be best_effort or strict")
+
+	f := c.Fusion
+	check(oneOf(f.Strategy, "", "rrf", "minmax", "zscore", "combmnz"),
+		"fusion.strategy must be one of rrf, minmax, zscore, combmnz")
+	check(f.VectorWeight >= 0 && f.KeywordWeight >= 0, "fusion weights must not be negative")
+	check(f.RRFK >= 0, "fusion.rrf_k must not be negative")
+
+	check(c.QueryUnderstanding.TimeoutMS >= 0, "query_understanding.timeout_ms must not be negative")
+	check(c.QueryUnderstanding.MaxSubQueries >= 0, "query_understanding.max_sub_queries must not be negative")
+
+	check(c.Rerank.TopN >= 0, "rerank.top_n must not be negative")
+	check(c.Rerank.TimeoutMS >= 0, "rerank.timeout_ms must not be negative")
+
+	check(oneOf(strings.ToLower(c.Logging.Level), "", "debug", "info", "warn", "error"),
+		"logging.level must be one of debug, info, warn, error")
+	check(oneOf(c.Logging.Format, "", "json", "text"), "logging.format must be json or text")
+	check(oneOf(c.Logging.Queries, "", "plain", "hash", "redact"),
+		"logging.queries must be one of plain, hash, redact")
+
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	check(c.Server.Port > 0 && c.Server.Port < 65536, "server.port must be between 1 and 65535")
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// envName returns the environment variable that overrides a dotted setting
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
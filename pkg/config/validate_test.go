@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// validConfig returns defaults plus the settings that have none
+func validConfig() *Config {
+	cfg := Default()
+	cfg.PostgreSQL.DSN = "postgres://localhost"
+	cfg.OpenSearch.URL = "http://localhost:9200"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string // one substring per expected problem; none means valid
+	}{
+		{name: "defaults", modify: func(c *Config) {}},
+		{
+			name:   "missing required",
+			modify: func(c *Config) { c.PostgreSQL.DSN = ""; c.OpenSearch.URL = " " },
+			want:   []string{"postgresql.dsn is required (set SMARTSEARCH_POSTGRESQL_DSN)", "opensearch.url is required"},
+		},
+		{name: "dimensionality", modify: func(c *Config) { c.Ollama.Options.Dimensionality = 0 }, want: []string{"dimensionality must be positive"}},
+		{name: "reduction", modify: func(c *Config) { c.Ollama.Options.Reduction = "svd" }, want: []string{"reduction must be one of"}},
+		{name: "iterative scan", modify: func(c *Config) { c.Search.IterativeScan = "on" }, want: []string{"search.iterative_scan"}},
+		{name: "max top k", modify: func(c *Config) { c.Search.DefaultTopK = 200 }, want: []string{"must not exceed search.max_top_k"}},
+		{name: "min score", modify: func(c *Config) { c.Search.DefaultMinScore = 1.5 }, want: []string{"default_min_score must be between 0 and 1"}},
+		{name: "short cursor secret", modify: func(c *Config) { c.Search.CursorSecret = "short" }, want: []string{"cursor_secret must be at least 16 bytes"}},
+		{name: "cursor secret", modify: func(c *Config) { c.Search.CursorSecret = strings.Repeat("k", 16) }},
+		{name: "failure mode", modify: func(c *Config) { c.Search.FailureMode = "lenient" }, want: []string{"failure_mode"}},
+		{name: "fusion strategy", modify: func(c *Config) { c.Fusion.Strategy = "bm25" }, want: []string{"fusion.strategy"}},
+		{name: "fusion weights", modify: func(c *Config) { c.Fusion.KeywordWeight = -1 }, want: []string{"fusion weights"}},
+		{name: "log level case-insensitive", modify: func(c *Config) { c.Logging.Level = "DEBUG" }},
+		{name: "sample ratio", modify: func(c *Config) { c.Tracing.SampleRatio = 2 }, want: []string{"sample_ratio"}},
+		{name: "port", modify: func(c *Config) { c.Server.Port = 70000 }, want: []string{"server.port"}},
+		{
+			name: "every problem reported",
+			modify: func(c *Config) {
+				c.Ollama.URL = ""
+				c.Rerank.TopN = -1
+				c.Logging.Format = "xml"
+			},
+			want: []string{"ollama.url is required", "rerank.top_n", "logging.format"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want valid", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() = %v, want a ValidationError", err)
+			}
+			if len(verr.Problems) != len(tt.want) {
+				t.Fatalf("problems = %q, want %d", verr.Problems, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(verr.Problems[i], want) {
+					t.Errorf("problem %d = %q, want it to contain %q", i, verr.Problems[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultWatchInterval is how often the config file is checked for changes
+const defaultWatchInterval = 5 * time.Second
+
+// reloadable lists the settings, by JSON path prefix, that take effect without
+// a restart. Changes to anything else are logged but ignored until restart.
+var reloadable = []string{
+	"ollama.chat_model",
+	"search.",
+	"fusion.",
+	"query_understanding.",
+	"rerank.",
+}
+
+// Change is a single setting that differs between two configs
+type Change struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+	// Secret values are never logged
+	Secret bool
+}
+
+func (c Change) String() string {
+	if c.Secret {
+		return c.Path + " changed"
+	}
+	return fmt.Sprintf("%s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// Reloadable reports whether the change takes effect without a restart
+func (c Change) Reloadable() bool {
+	for _, prefix := range reloadable {
+		if c.Path == prefix || (strings.HasSuffix(prefix, ".") && strings.HasPrefix(c.Path, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns every setting that differs between old and new
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	diffStruct(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), "", &changes)
+	return changes
+}
+
+func diffStruct(a, b reflect.Value, path string, changes *[]Change) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := jsonName(f)
+		if path != "" {
+			name = path + "." + name
+		}
+
+		if f.Type.Kind() == reflect.Struct {
+			diffStruct(a.Field(i), b.Field(i), name, changes)
+			continue
+		}
+		if a.Field(i).Interface() != b.Field(i).Interface() {
+			*changes = append(*changes, Change{
+				Path:   name,
+				Old:    a.Field(i).Interface(),
+				New:    b.Field(i).Interface(),
+				Secret: f.Tag.Get("secret") == "true",
+			})
+		}
+	}
+}
+
+// Watcher reloads the configuration when its file changes or the process
+// receives SIGHUP
+type Watcher struct {
+	path     string
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	current *Config
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher watches the config loaded from path, starting from current. An
+// empty path still reloads on SIGHUP, picking up only defaults and environment.
+func NewWatcher(path string, current *Config, logger *slog.Logger) *Watcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	w := &Watcher{
+		path:     path,
+		interval: defaultWatchInterval,
+		logger:   logger,
+		current:  current,
+	}
+	w.modTime, w.size = w.stat()
+	return w
+}
+
+// Current returns the most recently loaded configuration
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Run watches until ctx is done, calling apply with each new configuration
+// that loads and validates. Invalid configs are logged and the previous one
+// stays active.
+func (w *Watcher) Run(ctx context.Context, apply func(*Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			w.logger.Info("received SIGHUP, reloading config")
+			w.reload(apply)
+		case <-ticker.C:
+			if modTime, size := w.stat(); !modTime.Equal(w.modTime) || size != w.size {
+				w.logger.Info("config file changed, reloading", "path", w.path)
+				w.reload(apply)
+			}
+		}
+	}
+}
+
+func (w *Watcher) reload(apply func(*Config)) {
+	w.modTime, w.size = w.stat()
+
+	next, err := LoadConfig(w.path)
+	if err != nil {
+		w.logger.Warn("config reload failed, keeping current config", "error", err)
+		return
+	}
+
+	w.mu.Lock()
+	prev := w.current
+	w.current = next
+	w.mu.Unlock()
+
+	changes := Diff(prev, next)
+	if len(changes) == 0 {
+		w.logger.Info("config reloaded, no changes")
+		return
+	}
+	for _, change := range changes {
+		attrs := []any{"setting", change.Path, "restart_required", !change.Reloadable()}
+		if !change.Secret {
+			attrs = append(attrs, "old", change.Old, "new", change.New)
+		}
+		w.logger.Info("config changed", attrs...)
+	}
+
+	apply(next)
+}
+
+// stat returns the config file's modification time and size, or zero values
+// when there is no file
+func (w *Watcher) stat() (time.Time, int64) {
+	if w.path == "" {
+		return time.Time{}, 0
+	}
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old, next := validConfig(), validConfig()
+	next.Search.EfSearch = 200
+	next.Ollama.URL = "http://other:11434/v1"
+	next.OpenSearch.Password = "changed"
+
+	var paths []string
+	for _, change := range Diff(old, next) {
+		paths = append(paths, change.Path)
+		if change.Secret && change.String() != "opensearch.password changed" {
+			t.Errorf("secret change printed as %q", change.String())
+		}
+	}
+	want := []string{"opensearch.password", "ollama.url", "search.ef_search"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Diff paths = %v, want %v", paths, want)
+	}
+
+	if changes := Diff(old, validConfig()); len(changes) != 0 {
+		t.Errorf("identical configs differ: %v", changes)
+	}
+}
+
+func TestChangeReloadable(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"search.ef_search", true},
+		{"search.iterative_scan", true},
+		{"fusion.strategy", true},
+		{"rerank.top_n", true},
+		{"ollama.chat_model", true},
+		{"ollama.chat_model_extra", false},
+		{"ollama.embed_model", false},
+		{"ollama.url", false},
+		{"postgresql.dsn", false},
+		{"searchx.top_k", false},
+	}
+	for _, tt := range tests {
+		if got := (Change{Path: tt.path}).Reloadable(); got != tt.want {
+			t.Errorf("Reloadable(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
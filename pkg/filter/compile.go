@@ -0,0 +1,179 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SQL compiles the filter into conditions over the tools table aliased as "t".
+// Placeholders are numbered from firstArg. The returned clause is empty when
+// there are no constraints, otherwise it starts with " AND ".
+func (f *Filter) SQL(firstArg int) (string, []interface{}) {
+	if f.IsEmpty() {
+		return "", nil
+	}
+
+	var conds []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", firstArg+len(args)-1)
+	}
+
+	if len(f.IDs) > 0 {
+		conds = append(conds, "t.id = ANY("+arg(pq.Array(f.IDs))+"::text[])")
+	}
+	if len(f.Categories) > 0 {
+		conds = append(conds, "t.category = ANY("+arg(pq.Array(f.Categories))+"::text[])")
+	}
+	if len(f.TagsAny) > 0 {
+		conds = append(conds, "t.tags && "+arg(pq.Array(f.TagsAny))+"::text[]")
+	}
+	if len(f.TagsAll) > 0 {
+		conds = append(conds, "t.tags @> "+arg(pq.Array(f.TagsAll))+"::text[]")
+	}
+	if r := f.Version; r != nil {
+		for _, b := range []struct{ op, v string }{
+			{">", r.GT}, {">=", r.GTE}, {"<", r.LT}, {"<=", r.LTE},
+		} {
+			if b.v != "" {
+				conds = append(conds, "t.version_key "+b.op+" "+arg(b.v))
+			}
+		}
+	}
+	conds = append(conds, timeSQL("t.created_at", f.CreatedAt, arg)...)
+	conds = append(conds, timeSQL("t.updated_at", f.UpdatedAt, arg)...)
+	for _, param := range f.Parameters {
+		// Containment matches one element with every given field
+		match, _ := json.Marshal([]Parameter{param})
+		conds = append(conds, "t.parameters @> "+arg(string(match))+"::jsonb")
+	}
+
+	return " AND " + strings.Join(conds, " AND "), args
+}
+
+func timeSQL(column string, r *TimeRange, arg func(interface{}) string) []string {
+	if r == nil {
+		return nil
+	}
+
+	var conds []string
+	for _, b := range []struct {
+		op string
+		v  *time.Time
+	}{
+		{">", r.GT}, {">=", r.GTE}, {"<", r.LT}, {"<=", r.LTE},
+	} {
+		if b.v != nil {
+			conds = append(conds, column+" "+b.op+" "+arg(*b.v))
+		}
+	}
+	return conds
+}
+
+// OpenSearch compiles the filter into clauses for a bool query's filter context
+func (f *Filter) OpenSearch() []map[string]interface{} {
+	if f.IsEmpty() {
+		return nil
+	}
+
+	var clauses []map[string]interface{}
+	if len(f.IDs) > 0 {
+		clauses = append(clauses, map[string]interface{}{
+			"ids": map[string]interface{}{"values": f.IDs},
+		})
+	}
+	if len(f.Categories) > 0 {
+		clauses = append(clauses, terms("category", f.Categories))
+	}
+	if len(f.TagsAny) > 0 {
+		clauses = append(clauses, terms("tags", f.TagsAny))
+	}
+	for _, tag := range f.TagsAll {
+		clauses = append(clauses, map[string]interface{}{
+			"term": map[string]interface{}{"tags": tag},
+		})
+	}
+	if r := f.Version; r != nil {
+		bounds := map[string]interface{}{}
+		for op, v := range map[string]string{"gt": r.GT, "gte": r.GTE, "lt": r.LT, "lte": r.LTE} {
+			if v != "" {
+				bounds[op] = v
+			}
+		}
+		clauses = append(clauses, rangeClause("version_key", bounds))
+	}
+	if c := timeClause("created_at", f.CreatedAt); c != nil {
+		clauses = append(clauses, c)
+	}
+	if c := timeClause("updated_at", f.UpdatedAt); c != nil {
+		clauses = append(clauses, c)
+	}
+	for _, param := range f.Parameters {
+		clauses = append(clauses, parameterClause(param))
+	}
+
+	return clauses
+}
+
+// parameterClause matches tools with one parameter satisfying every field of param
+func parameterClause(param Parameter) map[string]interface{} {
+	must := []map[string]interface{}{
+		{"term": map[string]interface{}{"parameters.direction": param.Direction}},
+	}
+	if param.Name != "" {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"parameters.name": param.Name},
+		})
+	}
+	if param.Type != "" {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"parameters.type": param.Type},
+		})
+	}
+	if param.Required != nil {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"parameters.required": *param.Required},
+		})
+	}
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path": "parameters",
+			"query": map[string]interface{}{
+				"bool": map[string]interface{}{"filter": must},
+			},
+			// Indexes built before parameters were added match nothing
+			"ignore_unmapped": true,
+		},
+	}
+}
+
+func terms(field string, values []string) map[string]interface{} {
+	return map[string]interface{}{
+		"terms": map[string]interface{}{field: values},
+	}
+}
+
+func rangeClause(field string, bounds map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"range": map[string]interface{}{field: bounds},
+	}
+}
+
+func timeClause(field string, r *TimeRange) map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+
+	bounds := map[string]interface{}{}
+	for op, v := range map[string]*time.Time{"gt": r.GT, "gte": r.GTE, "lt": r.LT, "lte": r.LTE} {
+		if v != nil {
+			bounds[op] = v.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return rangeClause(field, bounds)
+}
@@ -0,0 +1,163 @@
+package filter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		firstArg int
+		want     string
+		args     []interface{}
+	}{
+		{name: "empty", body: `{}`, firstArg: 4},
+		{
+			name:     "ids and categories",
+			body:     `{"id": ["a", "b"], "category": "email"}`,
+			firstArg: 4,
+			want:     " AND t.id = ANY($4::text[]) AND t.category = ANY($5::text[])",
+			args:     []interface{}{pq.Array([]string{"a", "b"}), pq.Array([]string{"email"})},
+		},
+		{
+			name:     "tags",
+			body:     `{"tags": {"any": ["x"], "all": ["y"]}}`,
+			firstArg: 1,
+			want:     " AND t.tags && $1::text[] AND t.tags @> $2::text[]",
+			args:     []interface{}{pq.Array([]string{"x"}), pq.Array([]string{"y"})},
+		},
+		{
+			name:     "version range",
+			body:     `{"version": {"gt": "1", "lte": "2.5"}}`,
+			firstArg: 2,
+			want:     " AND t.version_key > $2 AND t.version_key <= $3",
+			args:     []interface{}{"000001.000000.000000", "000002.000005.000000"},
+		},
+		{
+			name:     "dates",
+			body:     `{"created_at": {"gte": "2024-01-01", "lt": "2024-02-01"}}`,
+			firstArg: 1,
+			want:     " AND t.created_at >= $1 AND t.created_at < $2",
+			args:     []interface{}{*date("2024-01-01"), *date("2024-02-01")},
+		},
+		{
+			name:     "parameter",
+			body:     `{"outputs": {"type": "string", "required": true}}`,
+			firstArg: 1,
+			want:     " AND t.parameters @> $1::jsonb",
+			args:     []interface{}{`[{"direction":"output","type":"string","required":true}]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseJSON(t, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, args := f.SQL(tt.firstArg)
+			if got != tt.want {
+				t.Errorf("SQL = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+
+	var nilFilter *Filter
+	if where, args := nilFilter.SQL(1); where != "" || args != nil {
+		t.Errorf("nil filter compiled to %q %v", where, args)
+	}
+}
+
+func TestOpenSearch(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "empty", body: `{}`, want: `null`},
+		{
+			name: "ids and categories",
+			body: `{"id": "a", "category": ["email", "chat"]}`,
+			want: `[{"ids": {"values": ["a"]}}, {"terms": {"category": ["email", "chat"]}}]`,
+		},
+		{
+			name: "tags any and all",
+			body: `{"tags": {"any": ["x", "y"], "all": ["z", "w"]}}`,
+			want: `[{"terms": {"tags": ["x", "y"]}}, {"term": {"tags": "z"}}, {"term": {"tags": "w"}}]`,
+		},
+		{
+			name: "exact version",
+			body: `{"version": "2.1.0"}`,
+			want: `[{"range": {"version_key": {"gte": "000002.000001.000000", "lte": "000002.000001.000000"}}}]`,
+		},
+		{
+			name: "dates in UTC",
+			body: `{"updated_at": {"gt": "2024-03-01T08:00:00+01:00"}}`,
+			want: `[{"range": {"updated_at": {"gt": "2024-03-01T07:00:00Z"}}}]`,
+		},
+		{
+			name: "parameter",
+			body: `{"inputs": {"name": "url", "required": false}}`,
+			want: `[{"nested": {
+				"path": "parameters",
+				"ignore_unmapped": true,
+				"query": {"bool": {"filter": [
+					{"term": {"parameters.direction": "input"}},
+					{"term": {"parameters.name": "url"}},
+					{"term": {"parameters.required": false}}
+				]}}
+			}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseJSON(t, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Compare as JSON, the form the clauses are sent in
+			data, err := json.Marshal(f.OpenSearch())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, want interface{}
+			json.Unmarshal(data, &got)
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("bad want: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("OpenSearch = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+// Both compilers must accept the same bounds for every range field
+func TestCompilersAgreeOnRanges(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := &Filter{CreatedAt: &TimeRange{GT: &ts, LTE: &ts}, Version: &Range{GTE: "a", LT: "b"}}
+
+	where, args := f.SQL(1)
+	if len(args) != 4 {
+		t.Errorf("SQL %q has %d args, want 4", where, len(args))
+	}
+	bounds := 0
+	for _, clause := range f.OpenSearch() {
+		for _, field := range clause["range"].(map[string]interface{}) {
+			bounds += len(field.(map[string]interface{}))
+		}
+	}
+	if bounds != 4 {
+		t.Errorf("OpenSearch has %d range bounds, want 4", bounds)
+	}
+}
@@ -0,0 +1,350 @@
+// Package filter implements the structured filter language accepted in
+// SearchRequest.Filters and compiles it for both retrieval backends.
+//
+// Supported fields:
+//
+//	"id", "category":           "x" | ["x", "y"] | {"eq": "x"} | {"in": ["x", "y"]}
+//	"tags":                     "x" | ["x", "y"] (any) | {"any": [...], "all": [...]}
+//	"version":                  "1.2" | {"eq"|"gt"|"gte"|"lt"|"lte": "1.2.0"}
+//	"created_at", "updated_at": {"gt"|"gte"|"lt"|"lte": "2024-01-31" | RFC 3339}
+//	"inputs", "outputs":        {"name": "url", "type": "string", "required": true} | [{...}, ...]
+//
+// Each inputs/outputs condition must match a single schema parameter; a list
+// requires a match for every condition. Parameter names are flattened paths,
+// e.g. "options.retries" or "attachments[]", and types are as reported by
+// schema.Flatten, e.g. "string" or "array<string>".
+//
+// All conditions are combined with AND. String matches are exact and
+// case-sensitive on both backends.
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter is a validated, backend-independent set of search constraints
+type Filter struct {
+	IDs        []string
+	Categories []string
+	TagsAny    []string
+	TagsAll    []string
+	Version    *Range
+	CreatedAt  *TimeRange
+	UpdatedAt  *TimeRange
+	Parameters []Parameter
+}
+
+// Schema parameter directions
+const (
+	DirectionInput  = "input"
+	DirectionOutput = "output"
+)
+
+// Parameter requires a tool to have a matching input or output schema
+// parameter. Empty fields match anything.
+type Parameter struct {
+	Direction string `json:"direction"`
+	Name      string `json:"name,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Required  *bool  `json:"required,omitempty"`
+}
+
+// Range bounds a version. Bounds hold VersionKey values; empty bounds are open.
+type Range struct {
+	GT, GTE, LT, LTE string
+}
+
+// TimeRange bounds a timestamp. Nil bounds are open.
+type TimeRange struct {
+	GT, GTE, LT, LTE *time.Time
+}
+
+// Error lists every problem found while parsing a filter
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid filters: " + strings.Join(e.Problems, "; ")
+}
+
+// IsEmpty reports whether the filter has no constraints
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (len(f.IDs) == 0 && len(f.Categories) == 0 &&
+		len(f.TagsAny) == 0 && len(f.TagsAll) == 0 &&
+		f.Version == nil && f.CreatedAt == nil && f.UpdatedAt == nil &&
+		len(f.Parameters) == 0)
+}
+
+// Parse validates raw request filters and converts them into a Filter.
+// A nil or empty map yields an empty filter.
+func Parse(raw map[string]interface{}) (*Filter, error) {
+	p := &parser{}
+	f := &Filter{}
+
+	// Iterate in a stable order so error messages are deterministic
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := raw[key]
+		switch key {
+		case "id":
+			f.IDs = p.stringSet(key, value)
+		case "category":
+			f.Categories = p.stringSet(key, value)
+		case "tags":
+			f.TagsAny, f.TagsAll = p.tags(key, value)
+		case "version":
+			f.Version = p.versionRange(key, value)
+		case "created_at":
+			f.CreatedAt = p.timeRange(key, value)
+		case "updated_at":
+			f.UpdatedAt = p.timeRange(key, value)
+		case "inputs":
+			f.Parameters = append(f.Parameters, p.parameters(key, value, DirectionInput)...)
+		case "outputs":
+			f.Parameters = append(f.Parameters, p.parameters(key, value, DirectionOutput)...)
+		default:
+			p.fail("%s: unknown filter field", key)
+		}
+	}
+
+	if len(p.problems) > 0 {
+		return nil, &Error{Problems: p.problems}
+	}
+	return f, nil
+}
+
+type parser struct {
+	problems []string
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	p.problems = append(p.problems, fmt.Sprintf(format, args...))
+}
+
+// stringSet parses an equality or membership condition
+func (p *parser) stringSet(key string, value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return p.strings(key, []interface{}{v})
+	case []interface{}:
+		return p.strings(key, v)
+	case map[string]interface{}:
+		if len(v) != 1 {
+			p.fail("%s: expected exactly one of eq or in", key)
+			return nil
+		}
+		if eq, ok := v["eq"]; ok {
+			s, ok := eq.(string)
+			if !ok {
+				p.fail("%s.eq: expected a string", key)
+				return nil
+			}
+			return p.strings(key, []interface{}{s})
+		}
+		if in, ok := v["in"]; ok {
+			list, ok := in.([]interface{})
+			if !ok {
+				p.fail("%s.in: expected an array of strings", key)
+				return nil
+			}
+			return p.strings(key+".in", list)
+		}
+		p.fail("%s: expected exactly one of eq or in", key)
+	default:
+		p.fail("%s: expected a string, an array of strings or an object", key)
+	}
+	return nil
+}
+
+// tags parses a tag condition into its any and all lists
+func (p *parser) tags(key string, value interface{}) (anyOf, allOf []string) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return p.stringSet(key, value), nil
+	}
+
+	for op, v := range obj {
+		list, ok := v.([]interface{})
+		if !ok {
+			p.fail("%s.%s: expected an array of strings", key, op)
+			continue
+		}
+		switch op {
+		case "any":
+			anyOf = p.strings(key+".any", list)
+		case "all":
+			allOf = p.strings(key+".all", list)
+		default:
+			p.fail("%s: unknown operator %q, expected any or all", key, op)
+		}
+	}
+	if len(obj) == 0 {
+		p.fail("%s: expected any or all", key)
+	}
+	return anyOf, allOf
+}
+
+// strings converts a JSON array into a non-empty list of non-empty strings
+func (p *parser) strings(key string, list []interface{}) []string {
+	if len(list) == 0 {
+		p.fail("%s: must not be empty", key)
+		return nil
+	}
+
+	out := make([]string, 0, len(list))
+	for i, item := range list {
+		s, ok := item.(string)
+		if !ok || s == "" {
+			p.fail("%s[%d]: expected a non-empty string", key, i)
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// versionRange parses an exact version or a range of versions
+func (p *parser) versionRange(key string, value interface{}) *Range {
+	if s, ok := value.(string); ok {
+		value = map[string]interface{}{"eq": s}
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok || len(obj) == 0 {
+		p.fail("%s: expected a version string or an object of eq/gt/gte/lt/lte", key)
+		return nil
+	}
+
+	r := &Range{}
+	for op, v := range obj {
+		s, ok := v.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			p.fail("%s.%s: expected a version string", key, op)
+			continue
+		}
+		k := VersionKey(s)
+		switch op {
+		case "eq":
+			r.GTE, r.LTE = k, k
+		case "gt":
+			r.GT = k
+		case "gte":
+			r.GTE = k
+		case "lt":
+			r.LT = k
+		case "lte":
+			r.LTE = k
+		default:
+			p.fail("%s: unknown operator %q", key, op)
+		}
+	}
+	if _, ok := obj["eq"]; ok && len(obj) > 1 {
+		p.fail("%s: eq cannot be combined with other operators", key)
+	}
+	return r
+}
+
+// timeRange parses a range of timestamps
+func (p *parser) timeRange(key string, value interface{}) *TimeRange {
+	obj, ok := value.(map[string]interface{})
+	if !ok || len(obj) == 0 {
+		p.fail("%s: expected an object of gt/gte/lt/lte", key)
+		return nil
+	}
+
+	r := &TimeRange{}
+	for op, v := range obj {
+		s, ok := v.(string)
+		if !ok {
+			p.fail("%s.%s: expected a date string", key, op)
+			continue
+		}
+		t, err := parseTime(s)
+		if err != nil {
+			p.fail("%s.%s: expected YYYY-MM-DD or RFC 3339, got %q", key, op, s)
+			continue
+		}
+		switch op {
+		case "gt":
+			r.GT = &t
+		case "gte":
+			r.GTE = &t
+		case "lt":
+			r.LT = &t
+		case "lte":
+			r.LTE = &t
+		default:
+			p.fail("%s: unknown operator %q", key, op)
+		}
+	}
+	return r
+}
+
+// parameters parses one parameter condition or a list of them
+func (p *parser) parameters(key string, value interface{}, direction string) []Parameter {
+	list, ok := value.([]interface{})
+	if !ok {
+		list = []interface{}{value}
+	}
+	if len(list) == 0 {
+		p.fail("%s: expected at least one parameter condition", key)
+		return nil
+	}
+
+	params := make([]Parameter, 0, len(list))
+	for i, item := range list {
+		itemKey := key
+		if len(list) > 1 {
+			itemKey = fmt.Sprintf("%s[%d]", key, i)
+		}
+		obj, ok := item.(map[string]interface{})
+		if !ok || len(obj) == 0 {
+			p.fail("%s: expected an object of name, type or required", itemKey)
+			continue
+		}
+
+		param := Parameter{Direction: direction}
+		for field, v := range obj {
+			switch field {
+			case "name", "type":
+				s, ok := v.(string)
+				if !ok || strings.TrimSpace(s) == "" {
+					p.fail("%s.%s: expected a non-empty string", itemKey, field)
+					continue
+				}
+				if field == "name" {
+					param.Name = s
+				} else {
+					param.Type = s
+				}
+			case "required":
+				b, ok := v.(bool)
+				if !ok {
+					p.fail("%s.required: expected a boolean", itemKey)
+					continue
+				}
+				param.Required = &b
+			default:
+				p.fail("%s: unknown parameter field %q, expected name, type or required", itemKey, field)
+			}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// parseTime accepts RFC 3339 timestamps or plain dates, which are taken as UTC midnight
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,176 @@
+package filter
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseJSON parses filters written as a JSON request body would send them
+func parseJSON(t *testing.T, body string) (*Filter, error) {
+	t.Helper()
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &raw); err != nil {
+		t.Fatalf("bad test input %s: %v", body, err)
+	}
+	return Parse(raw)
+}
+
+func date(s string) *time.Time {
+	t, err := parseTime(s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want *Filter
+	}{
+		{name: "empty", body: `{}`, want: &Filter{}},
+		{name: "category string", body: `{"category": "email"}`, want: &Filter{Categories: []string{"email"}}},
+		{name: "category list", body: `{"category": ["email", "chat"]}`, want: &Filter{Categories: []string{"email", "chat"}}},
+		{name: "id eq", body: `{"id": {"eq": "a"}}`, want: &Filter{IDs: []string{"a"}}},
+		{name: "id in", body: `{"id": {"in": ["a", "b"]}}`, want: &Filter{IDs: []string{"a", "b"}}},
+		{name: "tags any", body: `{"tags": ["x", "y"]}`, want: &Filter{TagsAny: []string{"x", "y"}}},
+		{
+			name: "tags any and all",
+			body: `{"tags": {"any": ["x"], "all": ["y", "z"]}}`,
+			want: &Filter{TagsAny: []string{"x"}, TagsAll: []string{"y", "z"}},
+		},
+		{
+			name: "exact version",
+			body: `{"version": "1.2"}`,
+			want: &Filter{Version: &Range{GTE: "000001.000002.000000", LTE: "000001.000002.000000"}},
+		},
+		{
+			name: "version range",
+			body: `{"version": {"gte": "1.0", "lt": "2"}}`,
+			want: &Filter{Version: &Range{GTE: "000001.000000.000000", LT: "000002.000000.000000"}},
+		},
+		{
+			name: "dates",
+			body: `{"created_at": {"gte": "2024-01-31"}, "updated_at": {"lt": "2024-02-01T12:00:00+02:00"}}`,
+			want: &Filter{
+				CreatedAt: &TimeRange{GTE: date("2024-01-31")},
+				UpdatedAt: &TimeRange{LT: date("2024-02-01T10:00:00Z")},
+			},
+		},
+		{
+			name: "single parameter",
+			body: `{"inputs": {"name": "url", "type": "string", "required": true}}`,
+			want: &Filter{Parameters: []Parameter{
+				{Direction: DirectionInput, Name: "url", Type: "string", Required: boolPtr(true)},
+			}},
+		},
+		{
+			name: "parameter list",
+			body: `{"inputs": [{"name": "to"}, {"type": "integer"}], "outputs": {"required": false}}`,
+			want: &Filter{Parameters: []Parameter{
+				{Direction: DirectionInput, Name: "to"},
+				{Direction: DirectionInput, Type: "integer"},
+				{Direction: DirectionOutput, Required: boolPtr(false)},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJSON(t, tt.body)
+			if err != nil {
+				t.Fatalf("Parse(%s): %v", tt.body, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%s) = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string // substrings, one per expected problem
+	}{
+		{name: "unknown field", body: `{"owner": "me"}`, want: []string{"owner: unknown filter field"}},
+		{name: "empty list", body: `{"category": []}`, want: []string{"category: must not be empty"}},
+		{name: "non-string item", body: `{"id": ["a", 3]}`, want: []string{"id[1]: expected a non-empty string"}},
+		{name: "eq and in", body: `{"id": {"eq": "a", "in": ["b"]}}`, want: []string{"expected exactly one of eq or in"}},
+		{name: "unknown tags operator", body: `{"tags": {"none": ["x"]}}`, want: []string{`unknown operator "none"`}},
+		{name: "eq with range", body: `{"version": {"eq": "1", "gt": "0"}}`, want: []string{"eq cannot be combined"}},
+		{name: "bad date", body: `{"created_at": {"gt": "yesterday"}}`, want: []string{`expected YYYY-MM-DD or RFC 3339, got "yesterday"`}},
+		{name: "date not a range", body: `{"updated_at": "2024-01-01"}`, want: []string{"updated_at: expected an object"}},
+		{name: "bad required", body: `{"outputs": {"required": "yes"}}`, want: []string{"outputs.required: expected a boolean"}},
+		{
+			name: "every problem reported",
+			body: `{"category": 1, "inputs": [{"name": ""}, {"color": "red"}]}`,
+			want: []string{"category: expected a string", "inputs[0].name", `inputs[1]: unknown parameter field "color"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJSON(t, tt.body)
+			var ferr *Error
+			if !errors.As(err, &ferr) {
+				t.Fatalf("Parse(%s) error = %v, want *Error", tt.body, err)
+			}
+			if len(ferr.Problems) != len(tt.want) {
+				t.Fatalf("got problems %q, want %d", ferr.Problems, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(ferr.Problems[i], want) {
+					t.Errorf("problem %d = %q, want it to contain %q", i, ferr.Problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	var nilFilter *Filter
+	if !nilFilter.IsEmpty() || !(&Filter{}).IsEmpty() {
+		t.Error("nil and zero filters should be empty")
+	}
+	if (&Filter{TagsAll: []string{"x"}}).IsEmpty() {
+		t.Error("a filter with tags should not be empty")
+	}
+}
+
+func TestVersionKey(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"1.3.2", "000001.000003.000002"},
+		{"v2", "000002.000000.000000"},
+		{" 1.2 ", "000001.000002.000000"},
+		{"1.2.3-beta.1", "000001.000002.000003"},
+		{"1.2.3.4", "000001.000002.000003"},
+		{"1.x", "000001.000000.000000"},
+		{"", "000000.000000.000000"},
+		{"10000000.1", "999999.000001.000000"},
+	}
+	for _, tt := range tests {
+		if got := VersionKey(tt.version); got != tt.want {
+			t.Errorf("VersionKey(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+
+	// Keys sort in semantic version order
+	ordered := []string{"0.9", "1.2", "1.10", "2.0.1", "10"}
+	for i := 1; i < len(ordered); i++ {
+		if VersionKey(ordered[i-1]) >= VersionKey(ordered[i]) {
+			t.Errorf("VersionKey(%q) should sort before VersionKey(%q)", ordered[i-1], ordered[i])
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// versionComponents is the number of numeric version components kept in a version key
+const versionComponents = 3
+
+// VersionKey normalizes a version string into a key whose lexicographic order
+// matches semantic version order, e.g. "1.3.2" becomes "000001.000003.000002".
+// Only the leading digits of the first three dot-separated components are used;
+// missing or non-numeric components count as zero and pre-release suffixes are
+// ignored. Both backends store this key so version ranges compare identically.
+func VersionKey(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	parts := strings.SplitN(version, ".", versionComponents+1)
+
+	keys := make([]string, versionComponents)
+	for i := range keys {
+		n := 0
+		if i < len(parts) {
+			for _, r := range parts[i] {
+				if r < '0' || r > '9' {
+					break
+				}
+				n = n*10 + int(r-'0')
+				if n > 999999 {
+					n = 999999
+				}
+			}
+		}
+		keys[i] = fmt.Sprintf("%06d", n)
+	}
+
+	return strings.Join(keys, ".")
+}
@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"smartsearch/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Attribute keys with special handling
+const (
+	// QueryKey holds user query text and is redacted or hashed per config
+	QueryKey = "query"
+	// ResponseKey holds raw LLM output, which can echo the query, and is
+	// redacted whenever queries are
+	ResponseKey = "llm_response"
+	// RequestIDKey correlates every log line from one request
+	RequestIDKey = "request_id"
+)
+
+// Query text handling modes
+const (
+	QueriesPlain  = "plain"
+	QueriesHash   = "hash"
+	QueriesRedact = "redact"
+)
+
+// RequestIDHeader is read from incoming requests and echoed on responses
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// New builds the logger described by cfg.Logging, writing to stderr
+func New(cfg *config.Config) *slog.Logger {
+	return NewWithWriter(os.Stderr, cfg)
+}
+
+// NewWithWriter builds the logger described by cfg.Logging, writing to w
+func NewWithWriter(w io.Writer, cfg *config.Config) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       parseLevel(cfg.Logging.Level),
+		ReplaceAttr: redactor(cfg.Logging.Queries),
+	}
+
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Logging.Format, "text") {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(handler)
+}
+
+func parseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// redactor rewrites query and LLM response attributes according to mode
+func redactor(mode string) func(groups []string, a slog.Attr) slog.Attr {
+	return func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key != QueryKey && a.Key != ResponseKey {
+			return a
+		}
+		switch mode {
+		case QueriesPlain:
+			return a
+		case QueriesRedact:
+			return slog.String(a.Key, "[redacted]")
+		default:
+			if a.Key == ResponseKey {
+				return slog.String(a.Key, "[redacted]")
+			}
+			return slog.String(a.Key, hashText(a.Value.String()))
+		}
+	}
+}
+
+// hashText returns a short, stable fingerprint so repeated queries can be
+// correlated without logging their text
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext returns logger annotated with the request ID from ctx
+func FromContext(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if id := RequestID(ctx); id != "" {
+		return logger.With(RequestIDKey, id)
+	}
+	return logger
+}
+
+// OrDefault returns logger, or the default logger when it is nil
+func OrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Middleware assigns each request an ID, taken from X-Request-ID when the
+// caller sends one, stores it in the request context and logs the request
+// once it completes
+func Middleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request",
+			slog.String(RequestIDKey, id),
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+		)
+	}
+}
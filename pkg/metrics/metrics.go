@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Pipeline stages timed by StageDuration
+const (
+	StageEmbedding     = "embedding"
+	StagePgvector      = "pgvector"
+	StageOpenSearch    = "opensearch"
+	StageFusion        = "fusion"
+	StageRerank        = "rerank"
+	StageUnderstanding = "understanding"
+)
+
+// Dependencies counted by DependencyErrors
+const (
+	DependencyPostgres   = "postgres"
+	DependencyOpenSearch = "opensearch"
+	DependencyOllama     = "ollama"
+)
+
+// Result sets measured by ResultCount
+const (
+	ResultsVector  = "vector"
+	ResultsKeyword = "keyword"
+	ResultsFused   = "fused"
+	ResultsFinal   = "final"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "smartsearch_http_requests_total",
+		Help: "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "smartsearch_http_request_duration_seconds",
+		Help:    "HTTP request latency by route and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	StageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "smartsearch_stage_duration_seconds",
+		Help:    "Latency of each search pipeline stage.",
+		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"stage"})
+
+	TokensUsed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "smartsearch_llm_tokens_total",
+		Help: "Tokens reported by the model server, by model and token kind.",
+	}, []string{"model", "kind"})
+
+	ResultCount = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "smartsearch_search_results",
+		Help:    "Number of results produced per search, by result set.",
+		Buckets: []float64{0, 1, 2, 5, 10, 20, 50, 100, 200, 500},
+	}, []string{"set"})
+
+	DependencyErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "smartsearch_dependency_errors_total",
+		Help: "Failed calls to external dependencies.",
+	}, []string{"dependency"})
+
+	LegFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "smartsearch_leg_failures_total",
+		Help: "Retrieval legs that errored or timed out, by leg and outcome.",
+	}, []string{"leg", "status"})
+)
+
+// ObserveStage records the time since start for a pipeline stage
+func ObserveStage(stage string, start time.Time) {
+	StageDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+}
+
+// ObserveResults records the size of a result set
+func ObserveResults(set string, n int) {
+	ResultCount.WithLabelValues(set).Observe(float64(n))
+}
+
+// DependencyError counts a failed call to a dependency
+func DependencyError(dependency string) {
+	DependencyErrors.WithLabelValues(dependency).Inc()
+}
+
+// LegFailure counts a retrieval leg that errored or timed out
+func LegFailure(leg, status string) {
+	LegFailures.WithLabelValues(leg, status).Inc()
+}
+
+// Middleware records request counts and latency per route. Requests that match
+// no route are grouped under "unmatched" to keep label cardinality bounded.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		HTTPRequests.WithLabelValues(route, method, strconv.Itoa(c.Writer.Status())).Inc()
+		HTTPDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
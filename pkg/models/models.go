@@ -1,53 +1,250 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
 )
 
 // Tool represents a searchable tool in the system
 type Tool struct {
-    ID          string                 `json:"id"`
-    Name        string                 `json:"name"`
-    Description string                 `json:"description"`
-    Category    string                 `json:"category"`
-    Tags        []string              `json:"tags"`
-    InputSchema map[string]interface{} `json:"input_schema"`
-    OutputSchema map[string]interface{} `json:"output_schema"`
-    Version     string                 `json:"version"`
-    CreatedAt   time.Time             `json:"created_at"`
-    UpdatedAt   time.Time             `json:"updated_at"`
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Category     string                 `json:"category"`
+	Tags         []string               `json:"tags"`
+	InputSchema  map[string]interface{} `json:"input_schema"`
+	OutputSchema map[string]interface{} `json:"output_schema"`
+	Version      string                 `json:"version"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+}
+
+// ToolFields lists the tool fields that can be selected in SearchRequest.Fields
+var ToolFields = []string{
+	"id", "name", "description", "category", "tags",
+	"input_schema", "output_schema", "version", "created_at", "updated_at",
+}
+
+// FieldsFull selects every tool field
+const FieldsFull = "full"
+
+// FieldSelection names the tool fields to return with each result. In JSON it
+// is an array of field names or the string "full".
+type FieldSelection []string
+
+func (f *FieldSelection) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*f = FieldSelection{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return errors.New("fields must be \"full\" or an array of field names")
+	}
+	*f = names
+	return nil
 }
 
 // SearchResult represents a single search result
 type SearchResult struct {
-    Tool            Tool    `json:"tool"`
-    Score           float64 `json:"score"`
-    VectorScore     float64 `json:"vector_score"`
-    KeywordScore    float64 `json:"keyword_score"`
-    RerankedScore   float64 `json:"reranked_score"`
-    Confidence      float64 `json:"confidence"`
-    Justification   string  `json:"justification,omitempty"`
+	Tool          Tool    `json:"tool"`
+	Score         float64 `json:"score"`
+	VectorScore   float64 `json:"vector_score"`
+	KeywordScore  float64 `json:"keyword_score"`
+	RerankedScore float64 `json:"reranked_score"`
+	Confidence    float64 `json:"confidence"`
+	Justification string  `json:"justification,omitempty"`
+
+	// Explanation is only set when the request asks for it
+	Explanation *Explanation `json:"explanation,omitempty"`
+
+	// Fields restricts the tool fields written to JSON; nil writes them all
+	Fields []string `json:"-"`
+}
+
+// MarshalJSON writes the result with its tool restricted to Fields
+func (r SearchResult) MarshalJSON() ([]byte, error) {
+	type plain SearchResult
+	if r.Fields == nil {
+		return json.Marshal(plain(r))
+	}
+
+	data, err := json.Marshal(r.Tool)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	tool := make(map[string]json.RawMessage, len(r.Fields))
+	for _, field := range r.Fields {
+		if value, ok := all[field]; ok {
+			tool[field] = value
+		}
+	}
+
+	// The outer Tool field shadows the embedded one
+	return json.Marshal(struct {
+		Tool map[string]json.RawMessage `json:"tool"`
+		plain
+	}{tool, plain(r)})
 }
 
-// SearchRequest represents a search query
+// SearchRequest represents a search query. The form tags bind the GET form
+// of the streaming endpoint, which takes filters as a JSON query parameter.
 type SearchRequest struct {
-    Query     string                 `json:"query"`
-    Filters   map[string]interface{} `json:"filters,omitempty"`
-    TopK      int                    `json:"top_k"`
-    MinScore  float64               `json:"min_score,omitempty"`
+	Query      string                 `json:"query" form:"query"`
+	Filters    map[string]interface{} `json:"filters,omitempty" form:"-"`
+	TopK       int                    `json:"top_k" form:"top_k" binding:"min=0"`
+	Offset     int                    `json:"offset,omitempty" form:"offset" binding:"min=0"`
+	PageSize   int                    `json:"page_size,omitempty" form:"page_size" binding:"min=0"`
+	Cursor     string                 `json:"cursor,omitempty" form:"cursor"`
+	MinScore   float64                `json:"min_score,omitempty" form:"min_score"`
+	EfSearch   int                    `json:"ef_search,omitempty" form:"ef_search" binding:"min=0,max=1000"`
+	Probes     int                    `json:"probes,omitempty" form:"probes" binding:"min=0,max=1000"`
+	Rerank     *bool                  `json:"rerank,omitempty" form:"rerank"`
+	Understand *bool                  `json:"understand,omitempty" form:"understand"`
+	Fusion     string                 `json:"fusion,omitempty" form:"fusion"`
+	Fields     FieldSelection         `json:"fields,omitempty" form:"fields"`
+	Explain    bool                   `json:"explain,omitempty" form:"explain"`
 }
 
 // SearchResponse represents the search results
 type SearchResponse struct {
-    Results []SearchResult `json:"results"`
-    Total   int           `json:"total"`
-    Time    float64       `json:"time_ms"`
+	Results []SearchResult `json:"results"`
+	// Total counts every candidate that passed the score threshold, across all pages
+	Total    int     `json:"total"`
+	Offset   int     `json:"offset"`
+	PageSize int     `json:"page_size"`
+	Time     float64 `json:"time_ms"`
+	// NextCursor fetches the following page of the same ranking; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// Degraded is set when a retrieval leg failed and results come from the other
+	Degraded bool                 `json:"degraded"`
+	Legs     map[string]LegStatus `json:"legs,omitempty"`
+
+	Understanding *QueryUnderstanding `json:"understanding,omitempty"`
+
+	// Explain describes the pipeline when the request asks for explanations
+	Explain *SearchExplanation `json:"explain,omitempty"`
+}
+
+// Retrieval leg outcomes
+const (
+	LegOK      = "ok"
+	LegError   = "error"
+	LegTimeout = "timeout"
+)
+
+// LegStatus reports how one retrieval leg fared across all queries of a search
+type LegStatus struct {
+	Status  string `json:"status"`
+	Results int    `json:"results"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Explanation describes how one result was scored and ranked
+type Explanation struct {
+	// Query is the query or sub-query whose score the result kept
+	Query   string             `json:"query"`
+	Vector  *LegExplanation    `json:"vector,omitempty"`
+	Keyword *LegExplanation    `json:"keyword,omitempty"`
+	Fusion  *FusionExplanation `json:"fusion,omitempty"`
+	Rerank  *RerankExplanation `json:"rerank,omitempty"`
+}
+
+// LegExplanation is a result's standing in one retrieval leg
+type LegExplanation struct {
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"`
+	// Distance is the pgvector cosine distance; the vector score is 1 - distance
+	Distance *float64 `json:"distance,omitempty"`
+	// Normalized and Normalization are set for score-based fusion strategies
+	Normalized    *float64       `json:"normalized,omitempty"`
+	Normalization *Normalization `json:"normalization,omitempty"`
+	// Details is the OpenSearch _explanation of the keyword score
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+// Normalization holds the statistics of the leg scores a result was normalized
+// against. Min-max normalization uses Min and Max, z-score Mean and StdDev.
+type Normalization struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+}
+
+// FusionExplanation shows the fusion formula with the result's values filled in
+type FusionExplanation struct {
+	Strategy string  `json:"strategy"`
+	Formula  string  `json:"formula"`
+	Score    float64 `json:"score"`
+}
+
+// RerankExplanation is the reranker's output for a result in the reranked head
+type RerankExplanation struct {
+	FusedRank     int     `json:"fused_rank"`
+	Rank          int     `json:"rank"`
+	Score         float64 `json:"score"`
+	Confidence    float64 `json:"confidence"`
+	Justification string  `json:"justification,omitempty"`
+}
+
+// SearchExplanation describes the search pipeline as a whole
+type SearchExplanation struct {
+	Queries    []string             `json:"queries"`
+	Fusion     string               `json:"fusion"`
+	Candidates int                  `json:"candidates"`
+	Threshold  ThresholdExplanation `json:"threshold"`
+	// RerankError is set when reranking failed and the fused order was kept
+	RerankError string `json:"rerank_error,omitempty"`
+	// Timings holds the duration of each pipeline stage in milliseconds. Stages
+	// that run once per query report the slowest query.
+	Timings map[string]float64 `json:"timings_ms"`
+}
+
+// ThresholdExplanation reports which fused results the minimum score removed
+type ThresholdExplanation struct {
+	MinScore float64             `json:"min_score"`
+	Passed   int                 `json:"passed"`
+	Dropped  []ThresholdDecision `json:"dropped"`
+}
+
+// ThresholdDecision is a result removed by the minimum score
+type ThresholdDecision struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
 }
 
 // QueryUnderstanding represents the analyzed query
 type QueryUnderstanding struct {
-    Intent        string   `json:"intent"`
-    ExpandedTerms []string `json:"expanded_terms"`
-    SubQueries    []string `json:"sub_queries"`
-    Filters       map[string]interface{} `json:"filters"`
-} 
\ No newline at end of file
+	Intent        string                 `json:"intent"`
+	ExpandedTerms []string               `json:"expanded_terms"`
+	SubQueries    []string               `json:"sub_queries"`
+	Filters       map[string]interface{} `json:"filters"`
+}
+
+// ToolPatch represents a partial update to a tool. Nil fields are left unchanged.
+type ToolPatch struct {
+	Name         *string                 `json:"name,omitempty"`
+	Description  *string                 `json:"description,omitempty"`
+	Category     *string                 `json:"category,omitempty"`
+	Tags         *[]string               `json:"tags,omitempty"`
+	InputSchema  *map[string]interface{} `json:"input_schema,omitempty"`
+	OutputSchema *map[string]interface{} `json:"output_schema,omitempty"`
+	Version      *string                 `json:"version,omitempty"`
+}
+
+// ToolListResponse represents a page of tools
+type ToolListResponse struct {
+	Tools  []Tool `json:"tools"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSearchResultMarshalJSON(t *testing.T) {
+	result := SearchResult{
+		Tool: Tool{
+			ID:          "gmail_send",
+			Name:        "Send email",
+			Description: "Sends an email",
+			Category:    "email",
+			Tags:        []string{"mail"},
+		},
+		Score:         0.8,
+		Justification: "sends mail",
+	}
+
+	tests := []struct {
+		name       string
+		fields     []string
+		toolFields []string
+	}{
+		{name: "all fields", fields: nil, toolFields: ToolFields},
+		{name: "projection", fields: []string{"id", "name", "tags"}, toolFields: []string{"id", "name", "tags"}},
+		{name: "unknown field ignored", fields: []string{"id", "owner"}, toolFields: []string{"id"}},
+		{name: "empty projection", fields: []string{}, toolFields: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := result
+			r.Fields = tt.fields
+			data, err := json.Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got struct {
+				Tool          map[string]json.RawMessage `json:"tool"`
+				Score         float64                    `json:"score"`
+				Justification string                     `json:"justification"`
+				Fields        json.RawMessage            `json:"Fields"`
+			}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			keys := make([]string, 0, len(got.Tool))
+			for key := range got.Tool {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			want := append([]string{}, tt.toolFields...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(keys, want) {
+				t.Errorf("tool fields = %v, want %v in %s", keys, want, data)
+			}
+
+			// Result-level fields are never projected away
+			if got.Score != 0.8 || got.Justification != "sends mail" {
+				t.Errorf("result fields lost: %s", data)
+			}
+			if got.Fields != nil {
+				t.Errorf("projection leaked into JSON: %s", data)
+			}
+		})
+	}
+}
+
+func TestFieldSelectionUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    FieldSelection
+		wantErr bool
+	}{
+		{body: `"full"`, want: FieldSelection{FieldsFull}},
+		{body: `["name", "tags"]`, want: FieldSelection{"name", "tags"}},
+		{body: `[]`, want: FieldSelection{}},
+		{body: `3`, wantErr: true},
+		{body: `[1]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got FieldSelection
+		err := json.Unmarshal([]byte(tt.body), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.body, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
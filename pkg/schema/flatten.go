@@ -0,0 +1,115 @@
+// Package schema extracts searchable parameter information from the JSON
+// Schema documents attached to tools.
+package schema
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxDepth bounds recursion into nested schemas
+const maxDepth = 8
+
+// Property is a single parameter found in a JSON Schema
+type Property struct {
+	// Path locates the property, e.g. "attachments[]" or "options.retries"
+	Path string `json:"name"`
+	// Type is the JSON Schema type, joined with "|" when several are allowed
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+}
+
+// Flatten walks an object schema and returns its properties, including nested
+// object properties and array items, in a stable order
+func Flatten(s map[string]interface{}) []Property {
+	var props []Property
+	flatten(s, "", &props, 0)
+	return props
+}
+
+func flatten(s map[string]interface{}, prefix string, out *[]Property, depth int) {
+	if depth > maxDepth {
+		return
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	if list, ok := s["required"].([]interface{}); ok {
+		for _, name := range list {
+			if name, ok := name.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		*out = append(*out, Property{
+			Path:        path,
+			Type:        typeOf(prop),
+			Description: describe(prop),
+			Required:    required[name],
+		})
+
+		flatten(prop, path, out, depth+1)
+		if items, ok := prop["items"].(map[string]interface{}); ok {
+			flatten(items, path+"[]", out, depth+1)
+		}
+	}
+}
+
+// typeOf returns the schema's type, which may be a string or a list of strings
+func typeOf(s map[string]interface{}) string {
+	switch t := s["type"].(type) {
+	case string:
+		if t == "array" {
+			if items, ok := s["items"].(map[string]interface{}); ok {
+				if itemType := typeOf(items); itemType != "" {
+					return "array<" + itemType + ">"
+				}
+			}
+		}
+		return t
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if v, ok := v.(string); ok {
+				types = append(types, v)
+			}
+		}
+		return strings.Join(types, "|")
+	}
+	return ""
+}
+
+// describe returns the property's description, falling back to its array item
+// description or title
+func describe(s map[string]interface{}) string {
+	if d, ok := s["description"].(string); ok && d != "" {
+		return d
+	}
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		if d, ok := items["description"].(string); ok && d != "" {
+			return d
+		}
+	}
+	if t, ok := s["title"].(string); ok {
+		return t
+	}
+	return ""
+}
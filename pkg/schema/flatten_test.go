@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   []Property
+	}{
+		{name: "empty", schema: `{}`, want: nil},
+		{
+			name: "required and sorted",
+			schema: `{
+				"type": "object",
+				"required": ["to"],
+				"properties": {
+					"to": {"type": "string", "description": "Recipient"},
+					"cc": {"type": "string"}
+				}
+			}`,
+			want: []Property{
+				{Path: "cc", Type: "string"},
+				{Path: "to", Type: "string", Description: "Recipient", Required: true},
+			},
+		},
+		{
+			name: "nested object",
+			schema: `{
+				"properties": {
+					"options": {
+						"type": "object",
+						"required": ["retries"],
+						"properties": {"retries": {"type": "integer", "title": "Retries"}}
+					}
+				}
+			}`,
+			want: []Property{
+				{Path: "options", Type: "object"},
+				{Path: "options.retries", Type: "integer", Description: "Retries", Required: true},
+			},
+		},
+		{
+			name: "array items",
+			schema: `{
+				"properties": {
+					"attachments": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"description": "A file to attach",
+							"properties": {"url": {"type": "string"}}
+						}
+					},
+					"labels": {"type": "array", "items": {"type": "string"}},
+					"raw": {"type": "array"}
+				}
+			}`,
+			want: []Property{
+				{Path: "attachments", Type: "array<object>", Description: "A file to attach"},
+				{Path: "attachments[].url", Type: "string"},
+				{Path: "labels", Type: "array<string>"},
+				{Path: "raw", Type: "array"},
+			},
+		},
+		{
+			name:   "type union",
+			schema: `{"properties": {"id": {"type": ["string", "integer", 3]}}}`,
+			want:   []Property{{Path: "id", Type: "string|integer"}},
+		},
+		{
+			name:   "malformed properties skipped",
+			schema: `{"required": "id", "properties": {"id": true, "name": {}}}`,
+			want:   []Property{{Path: "name"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &s); err != nil {
+				t.Fatalf("bad test schema: %v", err)
+			}
+			if got := Flatten(s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Flatten = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Recursion stops at maxDepth even for deeply nested schemas
+func TestFlattenDepth(t *testing.T) {
+	s := map[string]interface{}{}
+	inner := s
+	for i := 0; i < maxDepth+5; i++ {
+		next := map[string]interface{}{}
+		inner["properties"] = map[string]interface{}{"a": next}
+		inner = next
+	}
+
+	if got := len(Flatten(s)); got != maxDepth+1 {
+		t.Errorf("Flatten returned %d properties, want %d", got, maxDepth+1)
+	}
+}
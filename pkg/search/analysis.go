@@ -0,0 +1,234 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"smartsearch/pkg/logging"
+)
+
+// Analyzers applied to tool name and description
+const (
+	// textAnalyzer splits, folds and stems text at index time
+	textAnalyzer = "tool_text"
+	// searchAnalyzer does the same to queries and adds synonyms
+	searchAnalyzer = "tool_search"
+)
+
+// analysisOptions configures the analysis chain of new indexes
+type analysisOptions struct {
+	stemmer       string
+	asciiFolding  bool
+	wordDelimiter bool
+	synonymsFile  string
+}
+
+// settings builds the index analysis settings with the given synonym rules
+func (o analysisOptions) settings(synonyms []string) map[string]interface{} {
+	charFilters := map[string]interface{}{}
+	filters := map[string]interface{}{}
+	searchChar := []string{}
+	var indexFilters, searchFilters []string
+
+	if o.wordDelimiter {
+		// Index time keeps the whole identifier and adds its parts
+		filters["tool_word_delimiter"] = map[string]interface{}{
+			"type":              "word_delimiter_graph",
+			"preserve_original": true,
+		}
+		indexFilters = append(indexFilters, "tool_word_delimiter", "flatten_graph")
+
+		// Queries are split before tokenizing, since synonym rules cannot be
+		// parsed through a word delimiter
+		charFilters["tool_identifiers"] = map[string]interface{}{
+			"type":        "pattern_replace",
+			"pattern":     `(?<=\p{Ll})(?=\p{Lu})|[_.]`,
+			"replacement": " ",
+		}
+		searchChar = append(searchChar, "tool_identifiers")
+	}
+
+	indexFilters = append(indexFilters, "lowercase")
+	searchFilters = append(searchFilters, "lowercase")
+	if o.asciiFolding {
+		indexFilters = append(indexFilters, "asciifolding")
+		searchFilters = append(searchFilters, "asciifolding")
+	}
+
+	if len(synonyms) > 0 {
+		filters["tool_synonyms"] = map[string]interface{}{
+			"type":     "synonym_graph",
+			"synonyms": synonyms,
+		}
+		searchFilters = append(searchFilters, "tool_synonyms")
+	}
+
+	// Stem last so synonym rules match the words as written
+	if o.stemmer != "" && o.stemmer != "none" {
+		filters["tool_stemmer"] = map[string]interface{}{
+			"type":     "stemmer",
+			"language": o.stemmer,
+		}
+		indexFilters = append(indexFilters, "tool_stemmer")
+		searchFilters = append(searchFilters, "tool_stemmer")
+	}
+
+	return map[string]interface{}{
+		"char_filter": charFilters,
+		"filter":      filters,
+		"analyzer": map[string]interface{}{
+			textAnalyzer: map[string]interface{}{
+				"type":      "custom",
+				"tokenizer": "standard",
+				"filter":    indexFilters,
+			},
+			searchAnalyzer: map[string]interface{}{
+				"type":        "custom",
+				"tokenizer":   "standard",
+				"char_filter": searchChar,
+				"filter":      searchFilters,
+			},
+		},
+	}
+}
+
+// loadSynonyms reads Solr-format synonym rules, one per line, skipping blank
+// lines and # comments. An empty path means no synonyms.
+func loadSynonyms(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read synonyms file: %w", err)
+	}
+
+	var rules []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read synonyms file: %w", err)
+	}
+	return rules, nil
+}
+
+// ReloadSynonyms re-reads the synonyms file and rebuilds the index from source
+// with it. Analysis settings can only change on a closed index, so the rules go
+// into a new index version and the alias moves to it once it is complete;
+// searches keep using the old version until then, and a failure leaves it in
+// place. Only the previous version is kept. It returns the number of rules
+// loaded and the new index.
+func (c *OpenSearchClient) ReloadSynonyms(ctx context.Context, source ToolSource) (int, string, error) {
+	// Check the file before building anything
+	synonyms, err := loadSynonyms(c.analysis.synonymsFile)
+	if err != nil {
+		return 0, "", err
+	}
+
+	index, _, err := c.Reindex(ctx, source)
+	if err != nil {
+		return 0, "", err
+	}
+	if _, err := c.CleanupIndexes(ctx, 1); err != nil {
+		c.logger.Warn("failed to delete old indexes", "error", err)
+	}
+
+	c.logger.Info("reloaded synonyms", "index", index, "rules", len(synonyms))
+	return len(synonyms), index, nil
+}
+
+// synonymReloadTimeout bounds a background synonym reload
+const synonymReloadTimeout = 30 * time.Minute
+
+// ErrReloadInProgress is returned when a synonym reload is already running
+var ErrReloadInProgress = errors.New("a synonym reload is already running")
+
+// ReloadStatus describes the most recent synonym reload
+type ReloadStatus struct {
+	Running    bool       `json:"running"`
+	Rules      int        `json:"rules"`
+	Index      string     `json:"index,omitempty"`
+	Failed     bool       `json:"failed,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// SynonymReloader runs ReloadSynonyms in the background, one at a time, so a
+// reload outlives the request that started it and two reloads never race for
+// the same index version
+type SynonymReloader struct {
+	client *OpenSearchClient
+	source ToolSource
+
+	running sync.Mutex // held while a reload runs
+	mu      sync.RWMutex
+	status  *ReloadStatus
+}
+
+func NewSynonymReloader(client *OpenSearchClient, source ToolSource) *SynonymReloader {
+	return &SynonymReloader{client: client, source: source}
+}
+
+// Start checks the synonyms file and starts a reload, returning its initial
+// status. It fails with ErrReloadInProgress while another reload runs. The
+// reload keeps ctx's values but not its cancellation.
+func (r *SynonymReloader) Start(ctx context.Context) (ReloadStatus, error) {
+	synonyms, err := loadSynonyms(r.client.analysis.synonymsFile)
+	if err != nil {
+		return ReloadStatus{}, err
+	}
+	if !r.running.TryLock() {
+		return ReloadStatus{}, ErrReloadInProgress
+	}
+
+	status := ReloadStatus{Running: true, Rules: len(synonyms), StartedAt: time.Now()}
+	r.setStatus(status)
+
+	go func() {
+		defer r.running.Unlock()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), synonymReloadTimeout)
+		defer cancel()
+
+		rules, index, err := r.client.ReloadSynonyms(ctx, r.source)
+		finished := time.Now()
+		final := ReloadStatus{Rules: rules, Index: index, StartedAt: status.StartedAt, FinishedAt: &finished}
+		if err != nil {
+			logging.FromContext(ctx, r.client.logger).Error("synonym reload failed", "error", err)
+			final.Rules = status.Rules
+			final.Failed = true
+		}
+		r.setStatus(final)
+	}()
+	return status, nil
+}
+
+// Status returns the state of the most recent reload, or nil if none has run
+func (r *SynonymReloader) Status() *ReloadStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.status == nil {
+		return nil
+	}
+	status := *r.status
+	return &status
+}
+
+func (r *SynonymReloader) setStatus(status ReloadStatus) {
+	r.mu.Lock()
+	r.status = &status
+	r.mu.Unlock()
+}
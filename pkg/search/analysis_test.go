@@ -0,0 +1,115 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// waitForReload polls until the reload finishes
+func waitForReload(t *testing.T, reloader *SynonymReloader) *ReloadStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := reloader.Status(); status != nil && !status.Running {
+			return status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("reload did not finish")
+	return nil
+}
+
+func TestSynonymReloader(t *testing.T) {
+	cluster := newFakeCluster()
+	cluster.indexes["tools_v1"] = map[string]json.RawMessage{"a": nil}
+	cluster.aliases["tools"] = []string{"tools_v1"}
+	client := newTestClient(t, cluster.ServeHTTP)
+
+	source := newFakeSource(time.Now(), "a", "b")
+	release := make(chan struct{})
+	source.onAllTools = func() { <-release }
+
+	reloader := NewSynonymReloader(client, source)
+	if reloader.Status() != nil {
+		t.Error("Status() before any reload should be nil")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	status, err := reloader.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Running {
+		t.Errorf("Start() = %+v, want a running reload", status)
+	}
+
+	// A second reload is refused while the first runs
+	if _, err := reloader.Start(context.Background()); !errors.Is(err, ErrReloadInProgress) {
+		t.Errorf("second Start() = %v, want ErrReloadInProgress", err)
+	}
+
+	// The request that started the reload going away does not stop it
+	cancel()
+	close(release)
+
+	final := waitForReload(t, reloader)
+	if final.Failed || final.Index != "tools_v2" || final.FinishedAt == nil {
+		t.Errorf("final status = %+v, want tools_v2 built", final)
+	}
+	if got := cluster.aliases["tools"]; !reflect.DeepEqual(got, []string{"tools_v2"}) {
+		t.Errorf("alias points to %v, want [tools_v2]", got)
+	}
+	if got := cluster.docIDs("tools"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("alias has %v, want [a b]", got)
+	}
+
+	// The next reload can start once the first is done
+	source.onAllTools = nil
+	if _, err := reloader.Start(context.Background()); err != nil {
+		t.Errorf("Start() after the reload finished = %v", err)
+	}
+	if final := waitForReload(t, reloader); final.Index != "tools_v3" {
+		t.Errorf("second reload built %q, want tools_v3", final.Index)
+	}
+	if got := cluster.indexNames(); !reflect.DeepEqual(got, []string{"tools_v2", "tools_v3"}) {
+		t.Errorf("indexes = %v, want only the previous version kept", got)
+	}
+}
+
+func TestSynonymReloaderBadFile(t *testing.T) {
+	client := newTestClient(t, newFakeCluster().ServeHTTP)
+	client.analysis.synonymsFile = "testdata/missing-synonyms.txt"
+
+	reloader := NewSynonymReloader(client, newFakeSource(time.Now()))
+	if _, err := reloader.Start(context.Background()); err == nil {
+		t.Fatal("expected an error for a missing synonyms file")
+	}
+	if reloader.Status() != nil {
+		t.Error("a rejected reload should not be recorded")
+	}
+}
+
+func TestReindexCleansUpAfterCancel(t *testing.T) {
+	cluster := newFakeCluster()
+	cluster.indexes["tools_v1"] = map[string]json.RawMessage{"a": nil}
+	cluster.aliases["tools"] = []string{"tools_v1"}
+	client := newTestClient(t, cluster.ServeHTTP)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster.onRefresh = cancel
+
+	if _, _, err := client.Reindex(ctx, newFakeSource(time.Now(), "a")); err == nil {
+		t.Fatal("expected the cancelled reindex to fail")
+	}
+	if got := cluster.indexNames(); !reflect.DeepEqual(got, []string{"tools_v1"}) {
+		t.Errorf("indexes = %v, want the partial index deleted", got)
+	}
+	if got := cluster.aliases["tools"]; !reflect.DeepEqual(got, []string{"tools_v1"}) {
+		t.Errorf("alias points to %v, want it left on tools_v1", got)
+	}
+}
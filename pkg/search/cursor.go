@@ -0,0 +1,113 @@
+package search
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"smartsearch/pkg/models"
+	"strings"
+)
+
+// cursorVersion is bumped whenever the cursor encoding changes
+const cursorVersion = 3
+
+// errInvalidCursor is returned for cursors that cannot be decoded or were not
+// signed with the server's key
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursorState is the position of the next page. Each page re-runs the search
+// and cuts the page at Offset, so the cursor stays small enough for a URL.
+// Cursors are signed and bound to the request that issued them, so clients
+// cannot reuse one with different filters or settings.
+type cursorState struct {
+	Version  int    `json:"v"`
+	Request  string `json:"r"`
+	Offset   int    `json:"o"`
+	PageSize int    `json:"n"`
+}
+
+// requestFingerprint identifies every request field that shapes the ranking or
+// the returned results, leaving out the paging fields. Filters are marshalled
+// with sorted keys, so the same filters always give the same fingerprint.
+func requestFingerprint(req models.SearchRequest, fusion string, fields []string) (string, error) {
+	data, err := json.Marshal(struct {
+		Query      string                 `json:"q"`
+		Filters    map[string]interface{} `json:"f,omitempty"`
+		MinScore   float64                `json:"m,omitempty"`
+		EfSearch   int                    `json:"e,omitempty"`
+		Probes     int                    `json:"p,omitempty"`
+		Rerank     *bool                  `json:"r,omitempty"`
+		Understand *bool                  `json:"u,omitempty"`
+		Fusion     string                 `json:"s"`
+		Fields     []string               `json:"l"`
+		Explain    bool                   `json:"x,omitempty"`
+	}{
+		Query:      req.Query,
+		Filters:    req.Filters,
+		MinScore:   req.MinScore,
+		EfSearch:   req.EfSearch,
+		Probes:     req.Probes,
+		Rerank:     req.Rerank,
+		Understand: req.Understand,
+		Fusion:     fusion,
+		Fields:     fields,
+		Explain:    req.Explain,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// signCursor returns the HMAC-SHA256 of a cursor payload under key
+func signCursor(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// newCursor encodes and signs the position of the page at offset for the
+// request with the given fingerprint
+func newCursor(key []byte, request string, offset, pageSize int) (string, error) {
+	data, err := json.Marshal(cursorState{
+		Version:  cursorVersion,
+		Request:  request,
+		Offset:   offset,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signCursor(key, payload)), nil
+}
+
+// decodeCursor verifies a cursor's signature, parses it and checks that it was
+// issued for the request with the given fingerprint
+func decodeCursor(key []byte, cursor, request string) (*cursorState, error) {
+	payload, sig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, signCursor(key, payload)) {
+		return nil, errInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var state cursorState
+	if err := json.Unmarshal(data, &state); err != nil || state.Version != cursorVersion {
+		return nil, errInvalidCursor
+	}
+	if state.Request != request {
+		return nil, errors.New("cursor belongs to a different request")
+	}
+	return &state, nil
+}
@@ -0,0 +1,149 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"smartsearch/pkg/models"
+	"strings"
+	"testing"
+)
+
+var testCursorKey = []byte("0123456789abcdef")
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor, err := newCursor(testCursorKey, "req", 10, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := decodeCursor(testCursorKey, cursor, "req")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.Offset != 10 || state.PageSize != 5 {
+		t.Errorf("state = %+v", state)
+	}
+	if strings.Contains(cursor, "=") || strings.ContainsAny(cursor, "+/") {
+		t.Errorf("cursor %q is not URL-safe", cursor)
+	}
+	// Cursors carry a position, not results, so they fit in a GET query string
+	if len(cursor) > 200 {
+		t.Errorf("cursor is %d bytes", len(cursor))
+	}
+}
+
+func TestCursorRejected(t *testing.T) {
+	cursor, err := newCursor(testCursorKey, "req", 5, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, sig, _ := strings.Cut(cursor, ".")
+
+	// Re-encode the payload with a deeper offset, keeping the signature
+	data, _ := base64.RawURLEncoding.DecodeString(payload)
+	var state cursorState
+	json.Unmarshal(data, &state)
+	state.Offset = 500
+	forged, _ := json.Marshal(state)
+
+	tests := []struct {
+		name    string
+		key     []byte
+		cursor  string
+		request string
+	}{
+		{name: "forged offset", key: testCursorKey, cursor: base64.RawURLEncoding.EncodeToString(forged) + "." + sig, request: "req"},
+		{name: "other key", key: []byte("fedcba9876543210"), cursor: cursor, request: "req"},
+		{name: "other request", key: testCursorKey, cursor: cursor, request: "other"},
+		{name: "unsigned", key: testCursorKey, cursor: payload, request: "req"},
+		{name: "truncated signature", key: testCursorKey, cursor: cursor[:len(cursor)-2], request: "req"},
+		{name: "garbage", key: testCursorKey, cursor: "not a cursor", request: "req"},
+		{name: "empty", key: testCursorKey, cursor: "", request: "req"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if state, err := decodeCursor(tt.key, tt.cursor, tt.request); err == nil {
+				t.Errorf("decodeCursor accepted %q: %+v", tt.cursor, state)
+			}
+		})
+	}
+}
+
+// Cursors signed for an older encoding are rejected rather than misread
+func TestCursorVersion(t *testing.T) {
+	data, _ := json.Marshal(cursorState{Version: cursorVersion - 1, Request: "req"})
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	cursor := payload + "." + base64.RawURLEncoding.EncodeToString(signCursor(testCursorKey, payload))
+
+	if _, err := decodeCursor(testCursorKey, cursor, "req"); err != errInvalidCursor {
+		t.Errorf("error = %v, want errInvalidCursor", err)
+	}
+}
+
+func TestRequestFingerprint(t *testing.T) {
+	yes := true
+	base := func() models.SearchRequest {
+		return models.SearchRequest{
+			Query:   "send email",
+			Filters: map[string]interface{}{"category": "communication", "tags": map[string]interface{}{"$in": []interface{}{"smtp"}}},
+		}
+	}
+	fingerprint := func(req models.SearchRequest, fusion string, fields []string) string {
+		t.Helper()
+		f, err := requestFingerprint(req, fusion, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	want := fingerprint(base(), FusionRRF, defaultFields)
+
+	tests := []struct {
+		name   string
+		modify func(*models.SearchRequest)
+		fusion string
+		fields []string
+		same   bool
+	}{
+		{name: "identical", same: true},
+		{name: "paging fields", modify: func(r *models.SearchRequest) {
+			r.TopK, r.Offset, r.PageSize, r.Cursor = 5, 20, 7, "abc"
+		}, same: true},
+		{name: "filters rebuilt", modify: func(r *models.SearchRequest) {
+			r.Filters = map[string]interface{}{"tags": map[string]interface{}{"$in": []interface{}{"smtp"}}, "category": "communication"}
+		}, same: true},
+		{name: "query", modify: func(r *models.SearchRequest) { r.Query = "send mail" }},
+		{name: "filters", modify: func(r *models.SearchRequest) { r.Filters["category"] = "email" }},
+		{name: "no filters", modify: func(r *models.SearchRequest) { r.Filters = nil }},
+		{name: "min_score", modify: func(r *models.SearchRequest) { r.MinScore = 0.2 }},
+		{name: "ef_search", modify: func(r *models.SearchRequest) { r.EfSearch = 200 }},
+		{name: "probes", modify: func(r *models.SearchRequest) { r.Probes = 4 }},
+		{name: "rerank", modify: func(r *models.SearchRequest) { r.Rerank = &yes }},
+		{name: "understand", modify: func(r *models.SearchRequest) { r.Understand = &yes }},
+		{name: "explain", modify: func(r *models.SearchRequest) { r.Explain = true }},
+		{name: "fusion", fusion: FusionCombMNZ},
+		{name: "fields", fields: []string{"id", "tags"}},
+		{name: "full fields", fields: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base()
+			if tt.modify != nil {
+				tt.modify(&req)
+			}
+			fusion := FusionRRF
+			if tt.fusion != "" {
+				fusion = tt.fusion
+			}
+			fields := defaultFields
+			if tt.fields != nil {
+				fields = tt.fields
+			}
+			if got := fingerprint(req, fusion, fields); (got == want) != tt.same {
+				t.Errorf("fingerprint %s, base %s, want same = %v", got, want, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartsearch/pkg/models"
+	"time"
+)
+
+// Retrieval legs reported in SearchResponse.Legs
+const (
+	LegVector  = "vector"
+	LegKeyword = "keyword"
+)
+
+// Failure modes for search.failure_mode
+const (
+	// FailureModeBestEffort returns the other leg's results when one leg fails
+	FailureModeBestEffort = "best_effort"
+	// FailureModeStrict fails the request when either leg fails
+	FailureModeStrict = "strict"
+)
+
+const (
+	defaultVectorTimeout  = 5 * time.Second
+	defaultKeywordTimeout = 3 * time.Second
+)
+
+// runLeg calls search with its own deadline, reporting a deadline hit as a
+// timeout even when the backend returns its own cancellation error
+func runLeg(
+	ctx context.Context,
+	leg string,
+	timeout time.Duration,
+	search func(context.Context) ([]models.SearchResult, error),
+) ([]models.SearchResult, error) {
+	legCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results, err := search(legCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(legCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%s search timed out after %s: %w", leg, timeout, context.DeadlineExceeded)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s search failed: %w", leg, err)
+	}
+	return results, nil
+}
+
+// legStatus describes the outcome of one leg
+func legStatus(results []models.SearchResult, err error) models.LegStatus {
+	switch {
+	case err == nil:
+		return models.LegStatus{Status: models.LegOK, Results: len(results)}
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.LegStatus{Status: models.LegTimeout, Error: err.Error()}
+	default:
+		return models.LegStatus{Status: models.LegError, Error: err.Error()}
+	}
+}
+
+// mergeLegStatus combines the outcomes of the same leg across queries. A
+// failure in any query marks the leg as failed.
+func mergeLegStatus(a, b models.LegStatus) models.LegStatus {
+	merged := models.LegStatus{Status: a.Status, Results: a.Results + b.Results, Error: a.Error}
+	if merged.Status == models.LegOK || merged.Status == "" {
+		merged.Status = b.Status
+	}
+	if merged.Error == "" {
+		merged.Error = b.Error
+	}
+	return merged
+}
+
+// checkLegs returns the error that fails a query given each leg's error, or
+// nil when its results can be used: both legs succeeded, or one failed in
+// best-effort mode while the request itself is still live
+func checkLegs(failureMode string, ctxErr, vectorErr, keywordErr error) error {
+	switch {
+	case vectorErr == nil && keywordErr == nil:
+		return nil
+	case vectorErr != nil && keywordErr != nil, failureMode == FailureModeStrict, ctxErr != nil:
+		return errors.Join(vectorErr, keywordErr)
+	default:
+		return nil
+	}
+}
+
+// singleLegFuser adapts fuser to the results of one leg, giving that leg the
+// full weight so scores still span [0, 1] and min_score keeps its meaning
+func singleLegFuser(fuser Fuser, vectorOnly bool) Fuser {
+	weights := FusionWeights{Keyword: 1}
+	if vectorOnly {
+		weights = FusionWeights{Vector: 1}
+	}
+
+	switch f := fuser.(type) {
+	case *RRFFuser:
+		return &RRFFuser{K: f.K, Weights: weights}
+	case *WeightedSumFuser:
+		return &WeightedSumFuser{Normalize: f.Normalize, Weights: weights, name: f.name}
+	default:
+		// CombMNZ rewards agreement between legs, which one leg cannot give
+		return &WeightedSumFuser{Normalize: minMaxNormalize, Weights: weights, name: fuser.Name()}
+	}
+}
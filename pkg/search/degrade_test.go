@@ -0,0 +1,186 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"smartsearch/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunLeg(t *testing.T) {
+	backendErr := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		parentDone  bool
+		search      func(context.Context) ([]models.SearchResult, error)
+		wantResults int
+		wantErr     string
+		wantTimeout bool
+	}{
+		{
+			name: "success",
+			search: func(context.Context) ([]models.SearchResult, error) {
+				return leg("a", 1.0, "b", 0.5), nil
+			},
+			wantResults: 2,
+		},
+		{
+			name: "backend error",
+			search: func(context.Context) ([]models.SearchResult, error) {
+				return nil, backendErr
+			},
+			wantErr: "vector search failed: connection refused",
+		},
+		{
+			name: "leg deadline",
+			search: func(ctx context.Context) ([]models.SearchResult, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			wantErr:     "vector search timed out after 10ms",
+			wantTimeout: true,
+		},
+		{
+			name: "backend error after the leg deadline",
+			search: func(ctx context.Context) ([]models.SearchResult, error) {
+				<-ctx.Done()
+				return nil, backendErr
+			},
+			wantErr:     "vector search timed out after 10ms",
+			wantTimeout: true,
+		},
+		{
+			name:       "request cancelled",
+			parentDone: true,
+			search: func(ctx context.Context) ([]models.SearchResult, error) {
+				return nil, ctx.Err()
+			},
+			wantErr: "vector search failed: context canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.parentDone {
+				cancel()
+			}
+
+			results, err := runLeg(ctx, LegVector, 10*time.Millisecond, tt.search)
+			if len(results) != tt.wantResults {
+				t.Errorf("got %d results, want %d", len(results), tt.wantResults)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if got := errors.Is(err, context.DeadlineExceeded); got != tt.wantTimeout {
+				t.Errorf("errors.Is(err, DeadlineExceeded) = %v, want %v", got, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestLegStatus(t *testing.T) {
+	timeout := errors.Join(errors.New("keyword search timed out after 3s"), context.DeadlineExceeded)
+	failed := errors.New("keyword search failed: bad request")
+
+	tests := []struct {
+		name    string
+		results []models.SearchResult
+		err     error
+		want    models.LegStatus
+	}{
+		{"ok", leg("a", 1.0, "b", 0.5), nil, models.LegStatus{Status: models.LegOK, Results: 2}},
+		{"ok without results", nil, nil, models.LegStatus{Status: models.LegOK}},
+		{"timeout", nil, timeout, models.LegStatus{Status: models.LegTimeout, Error: timeout.Error()}},
+		{"error", nil, failed, models.LegStatus{Status: models.LegError, Error: failed.Error()}},
+		{"cancelled", nil, context.Canceled, models.LegStatus{Status: models.LegError, Error: "context canceled"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legStatus(tt.results, tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeLegStatus(t *testing.T) {
+	ok := func(n int) models.LegStatus { return models.LegStatus{Status: models.LegOK, Results: n} }
+	timeout := models.LegStatus{Status: models.LegTimeout, Error: "timed out"}
+	failed := models.LegStatus{Status: models.LegError, Error: "failed"}
+
+	tests := []struct {
+		name    string
+		queries []models.LegStatus
+		want    models.LegStatus
+	}{
+		{"single query", []models.LegStatus{ok(3)}, ok(3)},
+		{"all ok sums results", []models.LegStatus{ok(3), ok(2), ok(0)}, ok(5)},
+		{"later timeout", []models.LegStatus{ok(3), timeout}, models.LegStatus{Status: models.LegTimeout, Results: 3, Error: "timed out"}},
+		{"earlier error", []models.LegStatus{failed, ok(4)}, models.LegStatus{Status: models.LegError, Results: 4, Error: "failed"}},
+		{"first failure wins", []models.LegStatus{ok(1), timeout, failed}, models.LegStatus{Status: models.LegTimeout, Results: 1, Error: "timed out"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// search() folds each sub-query into a zero status
+			var got models.LegStatus
+			for _, status := range tt.queries {
+				got = mergeLegStatus(got, status)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLegs(t *testing.T) {
+	vectorErr := errors.New("vector search failed")
+	keywordErr := errors.New("keyword search timed out")
+
+	tests := []struct {
+		name       string
+		mode       string
+		ctxErr     error
+		vectorErr  error
+		keywordErr error
+		wantErr    bool
+	}{
+		{"both ok", FailureModeBestEffort, nil, nil, nil, false},
+		{"both ok strict", FailureModeStrict, nil, nil, nil, false},
+		{"vector failed best effort", FailureModeBestEffort, nil, vectorErr, nil, false},
+		{"keyword failed best effort", FailureModeBestEffort, nil, nil, keywordErr, false},
+		{"vector failed strict", FailureModeStrict, nil, vectorErr, nil, true},
+		{"keyword failed strict", FailureModeStrict, nil, nil, keywordErr, true},
+		{"both failed best effort", FailureModeBestEffort, nil, vectorErr, keywordErr, true},
+		{"request cancelled", FailureModeBestEffort, context.Canceled, nil, keywordErr, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLegs(tt.mode, tt.ctxErr, tt.vectorErr, tt.keywordErr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			for _, legErr := range []error{tt.vectorErr, tt.keywordErr} {
+				if err != nil && legErr != nil && !errors.Is(err, legErr) {
+					t.Errorf("error %v does not wrap %v", err, legErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"smartsearch/pkg/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pipeline stages reported in SearchExplanation.Timings
+const (
+	stageUnderstanding = "understanding"
+	stageVector        = "vector"
+	stageKeyword       = "keyword"
+	stageFusion        = "fusion"
+	stageMerge         = "merge"
+	stageThreshold     = "threshold"
+	stageRerank        = "rerank"
+	stageTotal         = "total"
+)
+
+// explainer collects the pipeline-level explanation of one search. A nil
+// explainer records nothing, so the pipeline can call it unconditionally.
+type explainer struct {
+	mu          sync.Mutex
+	explanation models.SearchExplanation
+}
+
+func newExplainer(req models.SearchRequest) *explainer {
+	if !req.Explain {
+		return nil
+	}
+	return &explainer{explanation: models.SearchExplanation{
+		Threshold: models.ThresholdExplanation{Dropped: []models.ThresholdDecision{}},
+		Timings:   make(map[string]float64),
+	}}
+}
+
+// observe records the time since start for a stage. Stages that run once per
+// query keep their slowest run, since the queries run in parallel.
+func (e *explainer) observe(stage string, start time.Time) {
+	if e == nil {
+		return
+	}
+	ms := float64(time.Since(start).Microseconds()) / 1000
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.explanation.Timings[stage] = math.Max(e.explanation.Timings[stage], ms)
+}
+
+// plan records the queries searched, the fusion strategy and the candidate pool
+func (e *explainer) plan(queries []string, fuser Fuser, candidates int) {
+	if e == nil {
+		return
+	}
+	e.explanation.Queries = queries
+	e.explanation.Fusion = fuser.Name()
+	e.explanation.Candidates = candidates
+}
+
+// threshold records which fused results fall below minScore, matching
+// applyScoreThreshold
+func (e *explainer) threshold(results []models.SearchResult, minScore float64) {
+	if e == nil {
+		return
+	}
+	t := &e.explanation.Threshold
+	t.MinScore = minScore
+	for _, result := range results {
+		if minScore <= 0 || result.Score >= minScore {
+			t.Passed++
+		} else {
+			t.Dropped = append(t.Dropped, models.ThresholdDecision{ID: result.Tool.ID, Score: result.Score})
+		}
+	}
+}
+
+// rerankFailed records a reranking failure that fell back to the fused order
+func (e *explainer) rerankFailed(err error) {
+	if e == nil {
+		return
+	}
+	e.explanation.RerankError = err.Error()
+}
+
+// finish records the total time and returns the explanation
+func (e *explainer) finish(start time.Time) *models.SearchExplanation {
+	if e == nil {
+		return nil
+	}
+	e.observe(stageTotal, start)
+	return &e.explanation
+}
+
+// explainFusion records the query and the fusion formula of each result of
+// one hybrid search
+func explainFusion(results []models.SearchResult, fuser Fuser, query string) {
+	for _, result := range results {
+		if result.Explanation == nil {
+			continue
+		}
+		result.Explanation.Query = query
+		result.Explanation.Fusion = &models.FusionExplanation{
+			Strategy: fuser.Name(),
+			Formula:  fusionFormula(fuser, result.Explanation, result.Score),
+			Score:    result.Score,
+		}
+	}
+}
+
+// fusionFormula writes out the fuser's score computation with the result's
+// ranks and normalized scores substituted
+func fusionFormula(fuser Fuser, e *models.Explanation, score float64) string {
+	vectorRank, vectorNorm := legValues(e.Vector)
+	keywordRank, keywordNorm := legValues(e.Keyword)
+
+	switch f := fuser.(type) {
+	case *RRFFuser:
+		var terms []string
+		if vectorRank > 0 {
+			terms = append(terms, fmt.Sprintf("%.4g/(%d+%d)", f.Weights.Vector, f.K, vectorRank))
+		}
+		if keywordRank > 0 {
+			terms = append(terms, fmt.Sprintf("%.4g/(%d+%d)", f.Weights.Keyword, f.K, keywordRank))
+		}
+		best := (f.Weights.Vector + f.Weights.Keyword) / float64(f.K+1)
+		return fmt.Sprintf("(%s) / %.6g = %.4f", strings.Join(terms, " + "), best, score)
+	case *WeightedSumFuser:
+		return fmt.Sprintf("%.4g*%.4f + %.4g*%.4f = %.4f",
+			f.Weights.Vector, vectorNorm, f.Weights.Keyword, keywordNorm, score)
+	case *CombMNZFuser:
+		hits := 0
+		if vectorRank > 0 {
+			hits++
+		}
+		if keywordRank > 0 {
+			hits++
+		}
+		return fmt.Sprintf("(%.4g*%.4f + %.4g*%.4f) * %d / 2 = %.4f",
+			f.Weights.Vector, vectorNorm, f.Weights.Keyword, keywordNorm, hits, score)
+	default:
+		return ""
+	}
+}
+
+// legValues returns a leg's rank and normalized score, or zeros when the leg
+// did not return the result
+func legValues(leg *models.LegExplanation) (int, float64) {
+	if leg == nil {
+		return 0, 0
+	}
+	if leg.Normalized == nil {
+		return leg.Rank, 0
+	}
+	return leg.Rank, *leg.Normalized
+}
+
+// mergeExplanations combines the explanations a result got from each leg
+func mergeExplanations(a, b *models.Explanation) *models.Explanation {
+	if a == nil && b == nil {
+		return nil
+	}
+	merged := &models.Explanation{}
+	for _, e := range []*models.Explanation{a, b} {
+		if e == nil {
+			continue
+		}
+		if e.Vector != nil {
+			merged.Vector = e.Vector
+		}
+		if e.Keyword != nil {
+			merged.Keyword = e.Keyword
+		}
+	}
+	return merged
+}
+
+// explainNormalization records a result's normalized score and the leg
+// statistics it was normalized against
+func explainNormalization(leg *models.LegExplanation, normalized float64, stats *models.Normalization) {
+	if leg == nil {
+		return
+	}
+	leg.Normalized = &normalized
+	leg.Normalization = stats
+}
+
+// legStatistics summarizes the raw scores of one leg
+func legStatistics(results []models.SearchResult) *models.Normalization {
+	stats := &models.Normalization{Count: len(results)}
+	if len(results) == 0 {
+		return stats
+	}
+
+	stats.Min, stats.Max = results[0].Score, results[0].Score
+	for _, result := range results {
+		stats.Min = math.Min(stats.Min, result.Score)
+		stats.Max = math.Max(stats.Max, result.Score)
+		stats.Mean += result.Score
+	}
+	stats.Mean /= float64(len(results))
+
+	variance := 0.0
+	for _, result := range results {
+		variance += (result.Score - stats.Mean) * (result.Score - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(results)))
+	return stats
+}
+
+// explainRerank records the reranker's output for the reranked head, given
+// the IDs of the head in fused order
+func explainRerank(reranked []models.SearchResult, fusedOrder []string) {
+	fusedRanks := make(map[string]int, len(fusedOrder))
+	for i, id := range fusedOrder {
+		fusedRanks[id] = i + 1
+	}
+	for i, result := range reranked {
+		if result.Explanation == nil {
+			continue
+		}
+		result.Explanation.Rerank = &models.RerankExplanation{
+			FusedRank:     fusedRanks[result.Tool.ID],
+			Rank:          i + 1,
+			Score:         result.RerankedScore,
+			Confidence:    result.Confidence,
+			Justification: result.Justification,
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package search
+
+import (
+	"math"
+	"smartsearch/pkg/models"
+	"testing"
+)
+
+// explainedLegs builds both legs with per-leg explanations, as the vector
+// store and OpenSearch client return them when a request sets explain
+func explainedLegs() (vectorResults, keywordResults []models.SearchResult) {
+	vectorResults = leg("a", 0.9, "b", 0.5, "c", 0.1)
+	for i := range vectorResults {
+		vectorResults[i].Explanation = &models.Explanation{
+			Vector: &models.LegExplanation{Rank: i + 1, Score: vectorResults[i].Score},
+		}
+	}
+	keywordResults = leg("b", 8.0, "d", 2.0)
+	for i := range keywordResults {
+		keywordResults[i].Explanation = &models.Explanation{
+			Keyword: &models.LegExplanation{Rank: i + 1, Score: keywordResults[i].Score},
+		}
+	}
+	return vectorResults, keywordResults
+}
+
+func TestFusionFormula(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     map[string]string
+	}{
+		{FusionRRF, map[string]string{
+			"a": "(0.5/(60+1)) / 0.0163934 = 0.5000",
+			"b": "(0.5/(60+2) + 0.5/(60+1)) / 0.0163934 = 0.9919",
+			"d": "(0.5/(60+2)) / 0.0163934 = 0.4919",
+		}},
+		{FusionMinMax, map[string]string{
+			"a": "0.5*1.0000 + 0.5*0.0000 = 0.5000",
+			"b": "0.5*0.5000 + 0.5*1.0000 = 0.7500",
+			"c": "0.5*0.0000 + 0.5*0.0000 = 0.0000",
+		}},
+		{FusionZScore, map[string]string{
+			"a": "0.5*0.8897 + 0.5*0.0000 = 0.4448",
+			"b": "0.5*0.5000 + 0.5*0.8413 = 0.6707",
+			"d": "0.5*0.0000 + 0.5*0.1587 = 0.0793",
+		}},
+		{FusionCombMNZ, map[string]string{
+			"a": "(0.5*1.0000 + 0.5*0.0000) * 1 / 2 = 0.2500",
+			"b": "(0.5*0.5000 + 0.5*1.0000) * 2 / 2 = 0.7500",
+			"d": "(0.5*0.0000 + 0.5*0.0000) * 1 / 2 = 0.0000",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			fuser, err := NewFuser(tt.strategy, FusionWeights{Vector: 1, Keyword: 1}, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results := fuser.Fuse(explainedLegs())
+			explainFusion(results, fuser, "q")
+
+			got := make(map[string]*models.Explanation)
+			for _, result := range results {
+				got[result.Tool.ID] = result.Explanation
+				fusion := result.Explanation.Fusion
+				if fusion == nil || fusion.Strategy != tt.strategy || fusion.Score != result.Score {
+					t.Errorf("%s: fusion explanation %+v does not match score %v", result.Tool.ID, fusion, result.Score)
+				}
+				if result.Explanation.Query != "q" {
+					t.Errorf("%s: query %q, want q", result.Tool.ID, result.Explanation.Query)
+				}
+			}
+			for id, want := range tt.want {
+				if formula := got[id].Fusion.Formula; formula != want {
+					t.Errorf("%s: formula %q, want %q", id, formula, want)
+				}
+			}
+		})
+	}
+}
+
+// Results found by both legs keep both leg explanations after fusion
+func TestFusionMergesLegExplanations(t *testing.T) {
+	fuser, _ := NewFuser(FusionRRF, FusionWeights{Vector: 1, Keyword: 1}, 0)
+	for _, result := range fuser.Fuse(explainedLegs()) {
+		e := result.Explanation
+		hasVector, hasKeyword := e.Vector != nil, e.Keyword != nil
+		wantVector := result.Tool.ID != "d"
+		wantKeyword := result.Tool.ID == "b" || result.Tool.ID == "d"
+		if hasVector != wantVector || hasKeyword != wantKeyword {
+			t.Errorf("%s: vector %v keyword %v, want %v %v", result.Tool.ID, hasVector, hasKeyword, wantVector, wantKeyword)
+		}
+		// Rank-based fusion does not normalize scores
+		for _, l := range []*models.LegExplanation{e.Vector, e.Keyword} {
+			if l != nil && (l.Normalized != nil || l.Normalization != nil) {
+				t.Errorf("%s: rrf recorded normalization %+v", result.Tool.ID, l)
+			}
+		}
+	}
+}
+
+func TestFusionNormalizationStatistics(t *testing.T) {
+	fuser, _ := NewFuser(FusionMinMax, FusionWeights{Vector: 1, Keyword: 1}, 0)
+	results := fuser.Fuse(explainedLegs())
+
+	var b *models.Explanation
+	for _, result := range results {
+		if result.Tool.ID == "b" {
+			b = result.Explanation
+		}
+	}
+	if b == nil || b.Vector == nil || b.Keyword == nil {
+		t.Fatalf("b explanation = %+v", b)
+	}
+
+	wantVector := models.Normalization{Count: 3, Min: 0.1, Max: 0.9, Mean: 0.5, StdDev: math.Sqrt(0.32 / 3)}
+	wantKeyword := models.Normalization{Count: 2, Min: 2, Max: 8, Mean: 5, StdDev: 3}
+	checkNormalization(t, "vector", b.Vector.Normalization, wantVector)
+	checkNormalization(t, "keyword", b.Keyword.Normalization, wantKeyword)
+	if *b.Vector.Normalized != 0.5 || *b.Keyword.Normalized != 1 {
+		t.Errorf("normalized = %v, %v, want 0.5, 1", *b.Vector.Normalized, *b.Keyword.Normalized)
+	}
+}
+
+func TestLegStatistics(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []models.SearchResult
+		want    models.Normalization
+	}{
+		{"empty", nil, models.Normalization{}},
+		{"single", leg("a", 0.7), models.Normalization{Count: 1, Min: 0.7, Max: 0.7, Mean: 0.7}},
+		{"equal", leg("a", 2.0, "b", 2.0), models.Normalization{Count: 2, Min: 2, Max: 2, Mean: 2}},
+		{"spread", leg("a", 9.0, "b", 5.0, "c", 4.0, "d", 2.0),
+			models.Normalization{Count: 4, Min: 2, Max: 9, Mean: 5, StdDev: math.Sqrt(6.5)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkNormalization(t, tt.name, legStatistics(tt.results), tt.want)
+		})
+	}
+}
+
+func TestExplainNormalization(t *testing.T) {
+	stats := &models.Normalization{Count: 2, Min: 1, Max: 3, Mean: 2, StdDev: 1}
+
+	l := &models.LegExplanation{Rank: 2, Score: 1}
+	explainNormalization(l, 0.25, stats)
+	if l.Normalized == nil || *l.Normalized != 0.25 || l.Normalization != stats {
+		t.Errorf("leg = %+v", l)
+	}
+
+	// A leg that did not return the result has nothing to record
+	explainNormalization(nil, 0.25, stats)
+}
+
+func checkNormalization(t *testing.T, name string, got *models.Normalization, want models.Normalization) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("%s: no normalization statistics", name)
+	}
+	if got.Count != want.Count ||
+		math.Abs(got.Min-want.Min) > 1e-9 || math.Abs(got.Max-want.Max) > 1e-9 ||
+		math.Abs(got.Mean-want.Mean) > 1e-9 || math.Abs(got.StdDev-want.StdDev) > 1e-9 {
+		t.Errorf("%s: statistics %+v, want %+v", name, *got, want)
+	}
+}
@@ -0,0 +1,286 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"smartsearch/pkg/models"
+	"sort"
+)
+
+// Fusion strategy names accepted in config and in SearchRequest.Fusion
+const (
+	FusionRRF     = "rrf"
+	FusionMinMax  = "minmax"
+	FusionZScore  = "zscore"
+	FusionCombMNZ = "combmnz"
+)
+
+const defaultRRFK = 60
+
+// Fuser combines the ranked results of the vector and keyword legs into one
+// ranking. Both inputs are sorted best first and fused scores are in [0, 1].
+// A tool found by only one leg scores lower than one found by both, so each
+// strategy has its own default threshold.
+type Fuser interface {
+	Name() string
+	Fuse(vectorResults, keywordResults []models.SearchResult) []models.SearchResult
+	// MinScore is the default minimum score, which a single-leg hit of at
+	// least half strength in the lighter-weighted leg clears
+	MinScore() float64
+}
+
+// FusionWeights sets the relative importance of each leg
+type FusionWeights struct {
+	Vector  float64
+	Keyword float64
+}
+
+// normalized returns the weights scaled to sum to one, defaulting to equal weights
+func (w FusionWeights) normalized() FusionWeights {
+	if w.Vector < 0 || w.Keyword < 0 || w.Vector+w.Keyword == 0 {
+		return FusionWeights{Vector: 0.5, Keyword: 0.5}
+	}
+	total := w.Vector + w.Keyword
+	return FusionWeights{Vector: w.Vector / total, Keyword: w.Keyword / total}
+}
+
+// lighter returns the smaller weight, ignoring a leg with no weight
+func (w FusionWeights) lighter() float64 {
+	if w.Vector <= 0 || w.Keyword <= 0 {
+		return math.Max(w.Vector, w.Keyword)
+	}
+	return math.Min(w.Vector, w.Keyword)
+}
+
+// NewFuser returns the fusion strategy with the given name
+func NewFuser(name string, weights FusionWeights, rrfK int) (Fuser, error) {
+	weights = weights.normalized()
+	switch name {
+	case FusionRRF:
+		if rrfK <= 0 {
+			rrfK = defaultRRFK
+		}
+		return &RRFFuser{K: rrfK, Weights: weights}, nil
+	case FusionMinMax:
+		return &WeightedSumFuser{Normalize: minMaxNormalize, Weights: weights, name: FusionMinMax}, nil
+	case FusionZScore:
+		return &WeightedSumFuser{Normalize: zScoreNormalize, Weights: weights, name: FusionZScore}, nil
+	case FusionCombMNZ:
+		return &CombMNZFuser{Weights: weights}, nil
+	default:
+		return nil, fmt.Errorf("unknown fusion strategy %q", name)
+	}
+}
+
+// RRFFuser implements weighted Reciprocal Rank Fusion: each leg contributes
+// weight / (K + rank). Scores are divided by the best possible score, so a tool
+// ranked first by both legs scores 1.
+type RRFFuser struct {
+	K       int
+	Weights FusionWeights
+}
+
+func (f *RRFFuser) Name() string { return FusionRRF }
+
+// MinScore lets single-leg hits in the lighter leg through down to about rank K
+func (f *RRFFuser) MinScore() float64 { return f.Weights.lighter() / 2 }
+
+func (f *RRFFuser) Fuse(vectorResults, keywordResults []models.SearchResult) []models.SearchResult {
+	k := float64(f.K)
+	best := (f.Weights.Vector + f.Weights.Keyword) / (k + 1)
+
+	return combine(vectorResults, keywordResults, func(vectorRank, keywordRank int, _, _ float64) float64 {
+		score := 0.0
+		if vectorRank > 0 {
+			score += f.Weights.Vector / (k + float64(vectorRank))
+		}
+		if keywordRank > 0 {
+			score += f.Weights.Keyword / (k + float64(keywordRank))
+		}
+		return score / best
+	}, nil)
+}
+
+// WeightedSumFuser normalizes each leg's scores into [0, 1] and takes their
+// weighted sum. A tool missing from a leg contributes zero for that leg.
+type WeightedSumFuser struct {
+	Normalize func(scores []float64) []float64
+	Weights   FusionWeights
+	name      string
+}
+
+func (f *WeightedSumFuser) Name() string { return f.name }
+
+func (f *WeightedSumFuser) MinScore() float64 { return f.Weights.lighter() / 2 }
+
+func (f *WeightedSumFuser) Fuse(vectorResults, keywordResults []models.SearchResult) []models.SearchResult {
+	return combine(vectorResults, keywordResults, func(_, _ int, vectorNorm, keywordNorm float64) float64 {
+		return f.Weights.Vector*vectorNorm + f.Weights.Keyword*keywordNorm
+	}, f.Normalize)
+}
+
+// CombMNZFuser takes the weighted sum of the min-max normalized scores and
+// multiplies it by the number of legs that returned the tool, rewarding
+// agreement between legs. The result is divided by its maximum of 2 to stay in
+// [0, 1]; with equal weights this is classic CombMNZ scaled by 1/4.
+type CombMNZFuser struct {
+	Weights FusionWeights
+}
+
+func (f *CombMNZFuser) Name() string { return FusionCombMNZ }
+
+// MinScore passes single-leg hits in the lighter leg with a normalized score
+// of at least 0.5
+func (f *CombMNZFuser) MinScore() float64 { return f.Weights.lighter() / 4 }
+
+func (f *CombMNZFuser) Fuse(vectorResults, keywordResults []models.SearchResult) []models.SearchResult {
+	return combine(vectorResults, keywordResults, func(vectorRank, keywordRank int, vectorNorm, keywordNorm float64) float64 {
+		hits := 0.0
+		if vectorRank > 0 {
+			hits++
+		}
+		if keywordRank > 0 {
+			hits++
+		}
+		return (f.Weights.Vector*vectorNorm + f.Weights.Keyword*keywordNorm) * hits / 2
+	}, minMaxNormalize)
+}
+
+// scoreFunc computes a fused score from 1-based ranks (0 when the leg did not
+// return the tool) and normalized leg scores (0 when missing)
+type scoreFunc func(vectorRank, keywordRank int, vectorNorm, keywordNorm float64) float64
+
+// combine unions both legs by tool ID, records the raw leg scores and applies
+// the score function. Results are sorted by fused score, then by tool ID so the
+// order is deterministic.
+func combine(
+	vectorResults, keywordResults []models.SearchResult,
+	score scoreFunc,
+	normalize func([]float64) []float64,
+) []models.SearchResult {
+	type entry struct {
+		result                  models.SearchResult
+		vectorRank, keywordRank int
+		vectorNorm, keywordNorm float64
+	}
+
+	vectorNorms := normalizeLeg(vectorResults, normalize)
+	keywordNorms := normalizeLeg(keywordResults, normalize)
+	var vectorStats, keywordStats *models.Normalization
+	if normalize != nil {
+		vectorStats = legStatistics(vectorResults)
+		keywordStats = legStatistics(keywordResults)
+	}
+
+	entries := make(map[string]*entry)
+	var order []string
+	for i, result := range vectorResults {
+		result.VectorScore = result.Score
+		entries[result.Tool.ID] = &entry{result: result, vectorRank: i + 1, vectorNorm: vectorNorms[i]}
+		order = append(order, result.Tool.ID)
+	}
+	for i, result := range keywordResults {
+		if e, ok := entries[result.Tool.ID]; ok {
+			e.result.KeywordScore = result.Score
+			e.keywordRank = i + 1
+			e.keywordNorm = keywordNorms[i]
+			e.result.Explanation = mergeExplanations(e.result.Explanation, result.Explanation)
+			continue
+		}
+		result.KeywordScore = result.Score
+		result.VectorScore = 0
+		entries[result.Tool.ID] = &entry{result: result, keywordRank: i + 1, keywordNorm: keywordNorms[i]}
+		order = append(order, result.Tool.ID)
+	}
+
+	results := make([]models.SearchResult, 0, len(entries))
+	for _, id := range order {
+		e := entries[id]
+		e.result.Score = score(e.vectorRank, e.keywordRank, e.vectorNorm, e.keywordNorm)
+		if e.result.Explanation != nil && normalize != nil {
+			explainNormalization(e.result.Explanation.Vector, e.vectorNorm, vectorStats)
+			explainNormalization(e.result.Explanation.Keyword, e.keywordNorm, keywordStats)
+		}
+		results = append(results, e.result)
+	}
+	sortResults(results)
+
+	return results
+}
+
+// normalizeLeg returns the normalized score of each result, or zeros when the
+// strategy does not use scores
+func normalizeLeg(results []models.SearchResult, normalize func([]float64) []float64) []float64 {
+	scores := make([]float64, len(results))
+	for i, result := range results {
+		scores[i] = result.Score
+	}
+	if normalize == nil {
+		return make([]float64, len(results))
+	}
+	return normalize(scores)
+}
+
+// minMaxNormalize scales scores linearly into [0, 1]. When all scores are equal
+// they are all mapped to 1.
+func minMaxNormalize(scores []float64) []float64 {
+	out := make([]float64, len(scores))
+	if len(scores) == 0 {
+		return out
+	}
+
+	lo, hi := scores[0], scores[0]
+	for _, s := range scores {
+		lo = math.Min(lo, s)
+		hi = math.Max(hi, s)
+	}
+	for i, s := range scores {
+		if hi == lo {
+			out[i] = 1
+		} else {
+			out[i] = (s - lo) / (hi - lo)
+		}
+	}
+	return out
+}
+
+// zScoreNormalize standardizes scores and maps them into [0, 1] with the
+// standard normal CDF, so the leg's mean score becomes 0.5. When all scores are
+// equal they are all mapped to 0.5.
+func zScoreNormalize(scores []float64) []float64 {
+	out := make([]float64, len(scores))
+	if len(scores) == 0 {
+		return out
+	}
+
+	mean := 0.0
+	for _, s := range scores {
+		mean += s
+	}
+	mean /= float64(len(scores))
+
+	variance := 0.0
+	for _, s := range scores {
+		variance += (s - mean) * (s - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(scores)))
+
+	for i, s := range scores {
+		z := 0.0
+		if stddev > 0 {
+			z = (s - mean) / stddev
+		}
+		out[i] = 0.5 * (1 + math.Erf(z/math.Sqrt2))
+	}
+	return out
+}
+
+// sortResults orders results by score descending, breaking ties by tool ID
+func sortResults(results []models.SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Tool.ID < results[j].Tool.ID
+	})
+}
@@ -0,0 +1,203 @@
+package search
+
+import (
+	"math"
+	"smartsearch/pkg/models"
+	"testing"
+)
+
+// leg builds one leg's results from alternating tool IDs and scores, best first
+func leg(pairs ...interface{}) []models.SearchResult {
+	var results []models.SearchResult
+	for i := 0; i < len(pairs); i += 2 {
+		results = append(results, models.SearchResult{
+			Tool:  models.Tool{ID: pairs[i].(string)},
+			Score: pairs[i+1].(float64),
+		})
+	}
+	return results
+}
+
+// phi is the standard normal CDF used by z-score normalization
+func phi(z float64) float64 {
+	return 0.5 * (1 + math.Erf(z/math.Sqrt2))
+}
+
+func scoresByID(results []models.SearchResult) map[string]float64 {
+	scores := make(map[string]float64, len(results))
+	for _, result := range results {
+		scores[result.Tool.ID] = result.Score
+	}
+	return scores
+}
+
+func TestFusers(t *testing.T) {
+	weights := FusionWeights{Vector: 0.7, Keyword: 0.3}
+	// "a" is vector-only, "b" is in both legs and "c" is keyword-only
+	vector := leg("a", 0.9, "b", 0.5)
+	keyword := leg("b", 8.0, "c", 2.0)
+
+	tests := []struct {
+		name     string
+		want     map[string]float64
+		minScore float64
+	}{
+		{
+			name: FusionRRF,
+			want: map[string]float64{
+				"a": 0.7,
+				"b": 0.7*61/62 + 0.3,
+				"c": 0.3 * 61 / 62,
+			},
+			minScore: 0.15,
+		},
+		{
+			name:     FusionMinMax,
+			want:     map[string]float64{"a": 0.7, "b": 0.3, "c": 0},
+			minScore: 0.15,
+		},
+		{
+			name: FusionZScore,
+			want: map[string]float64{
+				"a": 0.7 * phi(1),
+				"b": 0.7*phi(-1) + 0.3*phi(1),
+				"c": 0.3 * phi(-1),
+			},
+			minScore: 0.15,
+		},
+		{
+			name:     FusionCombMNZ,
+			want:     map[string]float64{"a": 0.35, "b": 0.3, "c": 0},
+			minScore: 0.075,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fuser, err := NewFuser(tt.name, weights, 60)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results := fuser.Fuse(vector, keyword)
+			got := scoresByID(results)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d results, want %d", len(got), len(tt.want))
+			}
+			for id, want := range tt.want {
+				if math.Abs(got[id]-want) > 1e-9 {
+					t.Errorf("score of %s = %v, want %v", id, got[id], want)
+				}
+			}
+			for i := 1; i < len(results); i++ {
+				if results[i].Score > results[i-1].Score {
+					t.Errorf("results not sorted by score: %v", got)
+				}
+			}
+			if math.Abs(fuser.MinScore()-tt.minScore) > 1e-9 {
+				t.Errorf("MinScore() = %v, want %v", fuser.MinScore(), tt.minScore)
+			}
+		})
+	}
+}
+
+func TestCombMNZWeights(t *testing.T) {
+	// With equal weights CombMNZ is the plain sum of normalized scores times
+	// the hit count, divided by 4
+	vector := leg("a", 0.9, "b", 0.5)
+	keyword := leg("a", 8.0, "c", 2.0)
+
+	tests := []struct {
+		weights  FusionWeights
+		want     map[string]float64
+		minScore float64
+	}{
+		{weights: FusionWeights{Vector: 1, Keyword: 1}, want: map[string]float64{"a": 1, "b": 0, "c": 0}, minScore: 0.125},
+		{weights: FusionWeights{Vector: 1, Keyword: 0}, want: map[string]float64{"a": 1, "b": 0, "c": 0}, minScore: 0.25},
+		{weights: FusionWeights{Vector: 0.25, Keyword: 0.75}, want: map[string]float64{"a": 1, "b": 0, "c": 0}, minScore: 0.0625},
+	}
+	for _, tt := range tests {
+		fuser, _ := NewFuser(FusionCombMNZ, tt.weights, 60)
+		got := scoresByID(fuser.Fuse(vector, keyword))
+		for id, want := range tt.want {
+			if math.Abs(got[id]-want) > 1e-9 {
+				t.Errorf("%+v: score of %s = %v, want %v", tt.weights, id, got[id], want)
+			}
+		}
+		if math.Abs(fuser.MinScore()-tt.minScore) > 1e-9 {
+			t.Errorf("%+v: MinScore() = %v, want %v", tt.weights, fuser.MinScore(), tt.minScore)
+		}
+	}
+
+	// A single-leg hit is scaled by its leg's weight
+	vectorHeavy, _ := NewFuser(FusionCombMNZ, FusionWeights{Vector: 0.8, Keyword: 0.2}, 60)
+	keywordHeavy, _ := NewFuser(FusionCombMNZ, FusionWeights{Vector: 0.2, Keyword: 0.8}, 60)
+	onlyVector := leg("a", 0.9, "b", 0.5)
+	if v, k := vectorHeavy.Fuse(onlyVector, nil)[0].Score, keywordHeavy.Fuse(onlyVector, nil)[0].Score; v <= k {
+		t.Errorf("vector-only hit scored %v with vector weight 0.8 and %v with 0.2", v, k)
+	}
+}
+
+func TestFusersLegScores(t *testing.T) {
+	fuser, _ := NewFuser(FusionRRF, FusionWeights{Vector: 1, Keyword: 1}, 60)
+	results := fuser.Fuse(leg("a", 0.9), leg("a", 8.0, "b", 2.0))
+
+	want := map[string][2]float64{"a": {0.9, 8}, "b": {0, 2}}
+	for _, result := range results {
+		w := want[result.Tool.ID]
+		if result.VectorScore != w[0] || result.KeywordScore != w[1] {
+			t.Errorf("%s: vector_score %v keyword_score %v, want %v", result.Tool.ID, result.VectorScore, result.KeywordScore, w)
+		}
+	}
+}
+
+// A strong hit from only one leg must clear the strategy's default threshold,
+// whichever leg found it
+func TestFusersSingleLegHitsClearMinScore(t *testing.T) {
+	legs := []struct {
+		name            string
+		vector, keyword []models.SearchResult
+		top             string
+	}{
+		{name: "vector only", vector: leg("a", 0.9, "b", 0.5), top: "a"},
+		{name: "keyword only", keyword: leg("c", 8.0, "d", 2.0), top: "c"},
+		{name: "disjoint legs, keyword hit", vector: leg("a", 0.9, "b", 0.5), keyword: leg("c", 8.0, "d", 2.0), top: "c"},
+		{name: "single result per leg", vector: leg("a", 0.9), keyword: leg("c", 8.0), top: "c"},
+	}
+
+	for _, strategy := range []string{FusionRRF, FusionMinMax, FusionZScore, FusionCombMNZ} {
+		for _, weights := range []FusionWeights{{Vector: 0.7, Keyword: 0.3}, {Vector: 0.3, Keyword: 0.7}, {Vector: 1, Keyword: 1}} {
+			fuser, err := NewFuser(strategy, weights, 60)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tt := range legs {
+				if strategy == FusionZScore && tt.name == "single result per leg" {
+					// A lone score has no spread, so z-score maps it to the mean
+					continue
+				}
+				scores := scoresByID(fuser.Fuse(tt.vector, tt.keyword))
+				if scores[tt.top] < fuser.MinScore() {
+					t.Errorf("%s %+v %s: %s scored %v, below min score %v",
+						strategy, weights, tt.name, tt.top, scores[tt.top], fuser.MinScore())
+				}
+			}
+		}
+	}
+}
+
+func TestSingleLegFuser(t *testing.T) {
+	for _, strategy := range []string{FusionRRF, FusionMinMax, FusionZScore, FusionCombMNZ} {
+		fuser, _ := NewFuser(strategy, FusionWeights{Vector: 0.7, Keyword: 0.3}, 60)
+		results := singleLegFuser(fuser, false).Fuse(nil, leg("c", 8.0, "d", 2.0))
+		if results[0].Tool.ID != "c" || results[0].Score < 0.5 {
+			t.Errorf("%s: top keyword-only result %s scored %v", strategy, results[0].Tool.ID, results[0].Score)
+		}
+	}
+}
+
+func TestNewFuserUnknown(t *testing.T) {
+	if _, err := NewFuser("bm25", FusionWeights{}, 0); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"smartsearch/pkg/logging"
+)
+
+const defaultMappingCheckInterval = 5 * time.Minute
+
+// errNotChecked is reported until the first mapping check completes
+var errNotChecked = errors.New("index mapping not checked yet")
+
+// IndexMonitor periodically compares the live index mapping with the expected
+// one in the background, so drift is visible without checking on every search
+type IndexMonitor struct {
+	client   *OpenSearchClient
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu        sync.RWMutex
+	lastErr   error
+	checkedAt time.Time
+}
+
+func NewIndexMonitor(client *OpenSearchClient, interval time.Duration, logger *slog.Logger) *IndexMonitor {
+	if interval <= 0 {
+		interval = defaultMappingCheckInterval
+	}
+	return &IndexMonitor{
+		client:   client,
+		interval: interval,
+		logger:   logging.OrDefault(logger),
+		lastErr:  errNotChecked,
+	}
+}
+
+// Run checks the mapping on every interval until ctx is done
+func (m *IndexMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Check compares the live mapping now and records the result
+func (m *IndexMonitor) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	drift, err := m.client.MappingDrift(ctx)
+	if err == nil && len(drift) > 0 {
+		err = fmt.Errorf("mapping drift: %s", strings.Join(drift, "; "))
+	}
+
+	m.mu.Lock()
+	changed := (err == nil) != (m.lastErr == nil)
+	m.lastErr = err
+	m.checkedAt = time.Now()
+	m.mu.Unlock()
+
+	switch {
+	case err != nil:
+		m.logger.Warn("index mapping check failed", "index", m.client.index, "error", err)
+	case changed:
+		m.logger.Info("index mapping matches definition", "index", m.client.index)
+	}
+	return err
+}
+
+// Status returns the result of the most recent check without contacting
+// OpenSearch, for use by health checks
+func (m *IndexMonitor) Status(context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.lastErr != nil && !m.checkedAt.IsZero() {
+		return fmt.Errorf("%w (checked %s ago)", m.lastErr, time.Since(m.checkedAt).Round(time.Second))
+	}
+	return m.lastErr
+}
@@ -0,0 +1,231 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"smartsearch/pkg/config"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/opensearch-project/opensearch-go"
+)
+
+// discardLogger keeps expected warnings out of the test output
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestClient returns a client for the alias "tools" backed by handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *OpenSearchClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The client checks the product before its first request
+		if r.Method == http.MethodGet && r.URL.Path == "/" {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"version": map[string]interface{}{"number": "2.11.0", "distribution": "opensearch"},
+			})
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := opensearch.NewClient(opensearch.Config{Addresses: []string{server.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewOpenSearchClient(client, "tools", &config.Config{}, discardLogger)
+}
+
+// writeJSON writes v as the response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// mappingServer serves the given live mapping for the tools index
+func mappingServer(mappings map[string]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/tools":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && r.URL.Path == "/tools/_mapping":
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"tools_v1": map[string]interface{}{"mappings": mappings},
+			})
+		default:
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"error": "not found"})
+		}
+	}
+}
+
+// liveMapping returns indexMappings as OpenSearch would return it, with
+// modify applied to the decoded copy
+func liveMapping(t *testing.T, modify func(properties map[string]interface{})) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(indexMappings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mapping map[string]interface{}
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		t.Fatal(err)
+	}
+	modify(mapping["properties"].(map[string]interface{}))
+	return mapping
+}
+
+func TestCompareMapping(t *testing.T) {
+	expected := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{"type": "text", "analyzer": "tool_text"},
+			"tags": map[string]interface{}{"type": "keyword"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		live map[string]interface{}
+		want []string
+	}{
+		{name: "identical", live: expected},
+		{
+			name: "extra live fields are not drift",
+			live: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"name":  map[string]interface{}{"type": "text", "analyzer": "tool_text", "norms": false},
+					"tags":  map[string]interface{}{"type": "keyword"},
+					"owner": map[string]interface{}{"type": "keyword"},
+				},
+			},
+		},
+		{
+			name: "missing field",
+			live: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "text", "analyzer": "tool_text"},
+				},
+			},
+			want: []string{"properties.tags is missing"},
+		},
+		{
+			name: "changed type",
+			live: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "text", "analyzer": "standard"},
+					"tags": map[string]interface{}{"type": "text"},
+				},
+			},
+			want: []string{
+				"properties.name.analyzer is standard, expected tool_text",
+				"properties.tags.type is text, expected keyword",
+			},
+		},
+		{
+			name: "object replaced by a scalar",
+			live: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"name": "text",
+					"tags": map[string]interface{}{"type": "keyword"},
+				},
+			},
+			want: []string{"properties.name.analyzer is missing", "properties.name.type is missing"},
+		},
+		{name: "empty live mapping", live: nil, want: []string{"properties is missing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareMapping(expected, tt.live, "")
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compareMapping() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareMappingMatchesDecodedJSON(t *testing.T) {
+	// Booleans and numbers come back from OpenSearch decoded from JSON, which
+	// must not count as drift
+	live := liveMapping(t, func(map[string]interface{}) {})
+	if drift := compareMapping(indexMappings(), live, ""); len(drift) > 0 {
+		t.Errorf("unexpected drift: %q", drift)
+	}
+}
+
+func TestIndexMonitor(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{name: "matching mapping", handler: mappingServer(liveMapping(t, func(map[string]interface{}) {}))},
+		{
+			name: "drift",
+			handler: mappingServer(liveMapping(t, func(properties map[string]interface{}) {
+				delete(properties, "tags")
+			})),
+			wantErr: "mapping drift: tools_v1: properties.tags is missing",
+		},
+		{
+			name: "missing index",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			wantErr: "index tools does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := NewIndexMonitor(newTestClient(t, tt.handler), 0, discardLogger)
+			if err := monitor.Status(context.Background()); err != errNotChecked {
+				t.Errorf("Status() before a check = %v, want %v", err, errNotChecked)
+			}
+
+			err := monitor.Check(context.Background())
+			status := monitor.Status(context.Background())
+			if tt.wantErr == "" {
+				if err != nil || status != nil {
+					t.Errorf("Check() = %v, Status() = %v, want nil", err, status)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Check() = %v, want %q", err, tt.wantErr)
+			}
+			if status == nil || !strings.Contains(status.Error(), tt.wantErr) {
+				t.Errorf("Status() = %v, want %q", status, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIndexMonitorRecovers(t *testing.T) {
+	drifted := true
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mappingServer(liveMapping(t, func(properties map[string]interface{}) {
+			if drifted {
+				delete(properties, "tags")
+			}
+		}))(w, r)
+	}
+	monitor := NewIndexMonitor(newTestClient(t, handler), 0, discardLogger)
+
+	if err := monitor.Check(context.Background()); err == nil {
+		t.Fatal("expected drift")
+	}
+	drifted = false
+	if err := monitor.Check(context.Background()); err != nil {
+		t.Fatalf("Check() after repair = %v", err)
+	}
+	if err := monitor.Status(context.Background()); err != nil {
+		t.Errorf("Status() after repair = %v", err)
+	}
+}
@@ -0,0 +1,444 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartsearch/pkg/models"
+)
+
+// bulkBatchSize is the number of tools sent per bulk request during a reindex
+const bulkBatchSize = 500
+
+// reindexCleanupTimeout bounds deleting the partial index after a failed reindex
+const reindexCleanupTimeout = 30 * time.Second
+
+// versionedIndex names the physical index for a version behind alias, e.g. tools_v3
+func versionedIndex(alias string, version int) string {
+	return fmt.Sprintf("%s_v%d", alias, version)
+}
+
+// indexVersion parses the version from a physical index name, reporting false
+// for indexes that do not belong to alias
+func indexVersion(alias, name string) (int, bool) {
+	suffix, ok := strings.CutPrefix(name, alias+"_v")
+	if !ok {
+		return 0, false
+	}
+	version, err := strconv.Atoi(suffix)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// createIndex creates a physical index with indexDefinition, optionally as the
+// write target of the alias
+func (c *OpenSearchClient) createIndex(ctx context.Context, name string, withAlias bool) error {
+	definition, err := c.indexDefinition()
+	if err != nil {
+		return err
+	}
+	if withAlias {
+		definition["aliases"] = map[string]interface{}{
+			c.index: map[string]interface{}{"is_write_index": true},
+		}
+	}
+
+	body, err := json.Marshal(definition)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index settings: %w", err)
+	}
+
+	res, err := c.client.Indices.Create(
+		name,
+		c.client.Indices.Create.WithContext(ctx),
+		c.client.Indices.Create.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error creating index %s: %s", name, res.String())
+	}
+	return nil
+}
+
+// aliasTargets returns the physical indexes the alias points to, or nothing
+// when the alias does not exist
+func (c *OpenSearchClient) aliasTargets(ctx context.Context) ([]string, error) {
+	res, err := c.client.Indices.GetAlias(
+		c.client.Indices.GetAlias.WithContext(ctx),
+		c.client.Indices.GetAlias.WithName(c.index),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index alias: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("error getting index alias: %s", res.String())
+	}
+
+	var aliases map[string]json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&aliases); err != nil {
+		return nil, fmt.Errorf("failed to decode index alias: %w", err)
+	}
+
+	targets := make([]string, 0, len(aliases))
+	for name := range aliases {
+		targets = append(targets, name)
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// IndexVersions returns every versioned index for the alias, newest first
+func (c *OpenSearchClient) IndexVersions(ctx context.Context) ([]string, error) {
+	res, err := c.client.Cat.Indices(
+		c.client.Cat.Indices.WithContext(ctx),
+		c.client.Cat.Indices.WithIndex(c.index+"_v*"),
+		c.client.Cat.Indices.WithFormat("json"),
+		c.client.Cat.Indices.WithH("index"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("error listing indexes: %s", res.String())
+	}
+
+	var rows []struct {
+		Index string `json:"index"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("failed to decode index list: %w", err)
+	}
+
+	versions := make(map[string]int, len(rows))
+	names := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if version, ok := indexVersion(c.index, row.Index); ok {
+			versions[row.Index] = version
+			names = append(names, row.Index)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return versions[names[i]] > versions[names[j]] })
+	return names, nil
+}
+
+// ToolSource is the source of truth a keyword index is rebuilt from
+type ToolSource interface {
+	AllTools(ctx context.Context) ([]models.Tool, error)
+	ToolsUpdatedSince(ctx context.Context, since time.Time) ([]models.Tool, error)
+	ToolIDs(ctx context.Context) ([]string, error)
+}
+
+// Reindex builds the next versioned index from source and atomically points
+// the alias at it, so searches never see a partial index. A concrete index
+// left over from before versioning is replaced in the same step. Tools written
+// while the reindex runs go to the previous index, so they are replayed from
+// source before the alias moves and again after it, for writes in between. It
+// returns the name of the new index and the number of tools in it.
+func (c *OpenSearchClient) Reindex(ctx context.Context, source ToolSource) (string, int, error) {
+	versions, err := c.IndexVersions(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	next := 1
+	if len(versions) > 0 {
+		latest, _ := indexVersion(c.index, versions[0])
+		next = latest + 1
+	}
+	name := versionedIndex(c.index, next)
+
+	started := time.Now()
+	tools, err := source.AllTools(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := c.createIndex(ctx, name, false); err != nil {
+		return "", 0, err
+	}
+	c.logger.Info("created index", "index", name)
+
+	fail := func(err error) (string, int, error) {
+		// ctx may be what failed, so clean up on one that is still live
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reindexCleanupTimeout)
+		defer cancel()
+		c.deleteIndexes(cleanupCtx, []string{name})
+		return "", 0, err
+	}
+
+	if err := c.bulkIndex(ctx, name, tools); err != nil {
+		return fail(err)
+	}
+	ids := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		ids[tool.ID] = true
+	}
+
+	if started, err = c.replayChanges(ctx, source, name, started, ids); err != nil {
+		return fail(err)
+	}
+	if err := c.refresh(ctx, name); err != nil {
+		return fail(err)
+	}
+	if err := c.swapAlias(ctx, name); err != nil {
+		return fail(err)
+	}
+	c.logger.Info("switched index alias", "alias", c.index, "index", name, "tools", len(ids))
+
+	// Writes between the first replay and the swap still went to the old index
+	if _, err := c.replayChanges(ctx, source, name, started, ids); err != nil {
+		return "", 0, fmt.Errorf("index %s is live but missing recent changes: %w", name, err)
+	}
+	return name, len(ids), nil
+}
+
+// reindexReplayMargin widens the replay window to cover clock skew between
+// hosts and transactions that started before the window and committed in it
+const reindexReplayMargin = time.Minute
+
+// replayChanges copies tools updated in source since the given time into
+// index, and deletes tools in ids that no longer exist in source. ids is
+// updated to the tools now in index. It returns the time the replay started,
+// for the next one.
+func (c *OpenSearchClient) replayChanges(ctx context.Context, source ToolSource, index string, since time.Time, ids map[string]bool) (time.Time, error) {
+	started := time.Now()
+	updated, err := source.ToolsUpdatedSince(ctx, since.Add(-reindexReplayMargin))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := c.bulkIndex(ctx, index, updated); err != nil {
+		return time.Time{}, err
+	}
+	for _, tool := range updated {
+		ids[tool.ID] = true
+	}
+
+	current, err := source.ToolIDs(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	exists := make(map[string]bool, len(current))
+	for _, id := range current {
+		exists[id] = true
+	}
+	var deleted []string
+	for id := range ids {
+		if !exists[id] {
+			deleted = append(deleted, id)
+		}
+	}
+	for _, id := range deleted {
+		if err := c.deleteFrom(ctx, index, id); err != nil {
+			return time.Time{}, err
+		}
+		delete(ids, id)
+	}
+
+	if len(updated) > 0 || len(deleted) > 0 {
+		c.logger.Info("replayed changes made during reindex", "index", index, "updated", len(updated), "deleted", len(deleted))
+	}
+	return started, nil
+}
+
+// swapAlias moves the alias to name in a single update
+func (c *OpenSearchClient) swapAlias(ctx context.Context, name string) error {
+	targets, err := c.aliasTargets(ctx)
+	if err != nil {
+		return err
+	}
+
+	var actions []map[string]interface{}
+	if len(targets) == 0 {
+		// An alias cannot share its name with an index, so drop any legacy one
+		exists, err := c.client.Indices.Exists([]string{c.index}, c.client.Indices.Exists.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to check if index exists: %w", err)
+		}
+		exists.Body.Close()
+
+		if exists.StatusCode != 404 {
+			actions = append(actions, map[string]interface{}{
+				"remove_index": map[string]interface{}{"index": c.index},
+			})
+		}
+	}
+	for _, target := range targets {
+		actions = append(actions, map[string]interface{}{
+			"remove": map[string]interface{}{"index": target, "alias": c.index},
+		})
+	}
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]interface{}{"index": name, "alias": c.index, "is_write_index": true},
+	})
+
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return fmt.Errorf("failed to marshal alias update: %w", err)
+	}
+
+	res, err := c.client.Indices.UpdateAliases(
+		bytes.NewReader(body),
+		c.client.Indices.UpdateAliases.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update index alias: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error updating index alias: %s", res.String())
+	}
+	return nil
+}
+
+// bulkIndex writes tools to the named index in batches
+func (c *OpenSearchClient) bulkIndex(ctx context.Context, index string, tools []models.Tool) error {
+	for start := 0; start < len(tools); start += bulkBatchSize {
+		end := min(start+bulkBatchSize, len(tools))
+
+		var body bytes.Buffer
+		enc := json.NewEncoder(&body)
+		for _, tool := range tools[start:end] {
+			action := map[string]interface{}{
+				"index": map[string]interface{}{"_index": index, "_id": tool.ID},
+			}
+			if err := enc.Encode(action); err != nil {
+				return fmt.Errorf("failed to marshal bulk action: %w", err)
+			}
+			if err := enc.Encode(newToolDocument(tool)); err != nil {
+				return fmt.Errorf("failed to marshal tool %s: %w", tool.ID, err)
+			}
+		}
+
+		res, err := c.client.Bulk(&body, c.client.Bulk.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to bulk index tools: %w", err)
+		}
+
+		var result struct {
+			Errors bool `json:"errors"`
+			Items  []map[string]struct {
+				ID    string          `json:"_id"`
+				Error json.RawMessage `json:"error"`
+			} `json:"items"`
+		}
+		if res.IsError() {
+			res.Body.Close()
+			return fmt.Errorf("error bulk indexing tools: %s", res.String())
+		}
+		err = json.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode bulk response: %w", err)
+		}
+
+		if result.Errors {
+			for _, item := range result.Items {
+				for _, op := range item {
+					if len(op.Error) > 0 {
+						return fmt.Errorf("error indexing tool %s: %s", op.ID, op.Error)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// refresh makes every document written to the index searchable
+func (c *OpenSearchClient) refresh(ctx context.Context, index string) error {
+	res, err := c.client.Indices.Refresh(
+		c.client.Indices.Refresh.WithContext(ctx),
+		c.client.Indices.Refresh.WithIndex(index),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to refresh index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error refreshing index %s: %s", index, res.String())
+	}
+	return nil
+}
+
+// CleanupIndexes deletes versioned indexes the alias no longer points to,
+// keeping the newest keep of them for rollback. It returns the deleted names.
+func (c *OpenSearchClient) CleanupIndexes(ctx context.Context, keep int) ([]string, error) {
+	versions, err := c.IndexVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := c.aliasTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		live[target] = true
+	}
+
+	var stale []string
+	kept := 0
+	for _, name := range versions {
+		if live[name] {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		stale = append(stale, name)
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	res, err := c.client.Indices.Delete(stale, c.client.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete indexes: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error deleting indexes: %s", res.String())
+	}
+	return stale, nil
+}
+
+// deleteIndexes removes indexes left behind by a failed reindex, logging rather
+// than returning errors so the original failure is reported
+func (c *OpenSearchClient) deleteIndexes(ctx context.Context, names []string) {
+	res, err := c.client.Indices.Delete(names, c.client.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		c.logger.Warn("failed to delete index", "index", names, "error", err)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		c.logger.Warn("failed to delete index", "index", names, "error", res.String())
+	}
+}
@@ -0,0 +1,413 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"smartsearch/pkg/models"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCluster is an in-memory OpenSearch with just enough of the index, alias
+// and document APIs for reindexing
+type fakeCluster struct {
+	mu      sync.Mutex
+	indexes map[string]map[string]json.RawMessage // index -> id -> document
+	aliases map[string][]string                   // alias -> indexes
+
+	failBulk  bool
+	onRefresh func() // runs before a refresh, between the first replay and the alias swap
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{
+		indexes: make(map[string]map[string]json.RawMessage),
+		aliases: make(map[string][]string),
+	}
+}
+
+// resolve returns the index a name or alias refers to
+func (f *fakeCluster) resolve(name string) string {
+	if targets := f.aliases[name]; len(targets) > 0 {
+		return targets[0]
+	}
+	return name
+}
+
+// docIDs lists the document IDs in an index or alias
+func (f *fakeCluster) docIDs(name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	for id := range f.indexes[f.resolve(name)] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (f *fakeCluster) indexNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for name := range f.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/_refresh") && f.onRefresh != nil {
+		f.onRefresh()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "_cat" && parts[1] == "indices":
+		prefix := strings.TrimSuffix(parts[2], "*")
+		rows := []map[string]string{}
+		for name := range f.indexes {
+			if strings.HasPrefix(name, prefix) {
+				rows = append(rows, map[string]string{"index": name})
+			}
+		}
+		writeJSON(w, http.StatusOK, rows)
+
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "_alias":
+		targets := f.aliases[parts[1]]
+		if len(targets) == 0 {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"error": "alias missing"})
+			return
+		}
+		body := make(map[string]interface{})
+		for _, target := range targets {
+			body[target] = map[string]interface{}{"aliases": map[string]interface{}{parts[1]: map[string]interface{}{}}}
+		}
+		writeJSON(w, http.StatusOK, body)
+
+	case r.Method == http.MethodPost && r.URL.Path == "/_aliases":
+		var update struct {
+			Actions []map[string]struct {
+				Index string `json:"index"`
+				Alias string `json:"alias"`
+			} `json:"actions"`
+		}
+		json.NewDecoder(r.Body).Decode(&update)
+		for _, action := range update.Actions {
+			for op, a := range action {
+				switch op {
+				case "add":
+					f.aliases[a.Alias] = append(f.aliases[a.Alias], a.Index)
+				case "remove":
+					var kept []string
+					for _, target := range f.aliases[a.Alias] {
+						if target != a.Index {
+							kept = append(kept, target)
+						}
+					}
+					f.aliases[a.Alias] = kept
+				case "remove_index":
+					delete(f.indexes, a.Index)
+				}
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"acknowledged": true})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/_bulk":
+		var items []interface{}
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 1<<20), 1<<20)
+		for scanner.Scan() {
+			var action map[string]struct {
+				Index string `json:"_index"`
+				ID    string `json:"_id"`
+			}
+			json.Unmarshal(scanner.Bytes(), &action)
+			scanner.Scan()
+			target := action["index"]
+			if f.failBulk {
+				items = append(items, map[string]interface{}{"index": map[string]interface{}{
+					"_id": target.ID, "error": map[string]interface{}{"type": "mapper_parsing_exception"},
+				}})
+				continue
+			}
+			index := f.resolve(target.Index)
+			if f.indexes[index] == nil {
+				f.indexes[index] = make(map[string]json.RawMessage)
+			}
+			f.indexes[index][target.ID] = append(json.RawMessage(nil), scanner.Bytes()...)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"errors": f.failBulk, "items": items})
+
+	case r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "_refresh":
+		writeJSON(w, http.StatusOK, map[string]interface{}{})
+
+	case r.Method == http.MethodPut && len(parts) == 1:
+		if _, ok := f.indexes[parts[0]]; ok {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "resource_already_exists_exception"})
+			return
+		}
+		f.indexes[parts[0]] = make(map[string]json.RawMessage)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"acknowledged": true})
+
+	case r.Method == http.MethodHead && len(parts) == 1:
+		if _, ok := f.indexes[f.resolve(parts[0])]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodDelete && len(parts) == 3 && parts[1] == "_doc":
+		docs := f.indexes[f.resolve(parts[0])]
+		if _, ok := docs[parts[2]]; !ok {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"result": "not_found"})
+			return
+		}
+		delete(docs, parts[2])
+		writeJSON(w, http.StatusOK, map[string]interface{}{"result": "deleted"})
+
+	case r.Method == http.MethodDelete && len(parts) == 1:
+		for _, name := range strings.Split(parts[0], ",") {
+			delete(f.indexes, name)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"acknowledged": true})
+
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"error": r.Method + " " + r.URL.Path})
+	}
+}
+
+// fakeSource is an in-memory ToolSource whose updated_at cutoff matches
+// vector.VectorStore.ToolsUpdatedSince
+type fakeSource struct {
+	mu       sync.Mutex
+	tools    map[string]models.Tool
+	since    []time.Time
+	replayed [][]string
+
+	onAllTools func() // runs after AllTools takes its snapshot
+}
+
+func newFakeSource(updatedAt time.Time, ids ...string) *fakeSource {
+	s := &fakeSource{tools: make(map[string]models.Tool)}
+	for _, id := range ids {
+		s.tools[id] = models.Tool{ID: id, Name: id, UpdatedAt: updatedAt}
+	}
+	return s
+}
+
+// write creates or updates a tool now
+func (s *fakeSource) write(id, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools[id] = models.Tool{ID: id, Name: name, UpdatedAt: time.Now()}
+}
+
+func (s *fakeSource) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tools, id)
+}
+
+func (s *fakeSource) sorted(keep func(models.Tool) bool) []models.Tool {
+	var tools []models.Tool
+	for _, tool := range s.tools {
+		if keep(tool) {
+			tools = append(tools, tool)
+		}
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].ID < tools[j].ID })
+	return tools
+}
+
+func (s *fakeSource) AllTools(context.Context) ([]models.Tool, error) {
+	s.mu.Lock()
+	tools := s.sorted(func(models.Tool) bool { return true })
+	s.mu.Unlock()
+	if s.onAllTools != nil {
+		s.onAllTools()
+	}
+	return tools, nil
+}
+
+func (s *fakeSource) ToolsUpdatedSince(_ context.Context, since time.Time) ([]models.Tool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tools := s.sorted(func(tool models.Tool) bool { return !tool.UpdatedAt.Before(since) })
+	ids := make([]string, len(tools))
+	for i, tool := range tools {
+		ids[i] = tool.ID
+	}
+	s.since = append(s.since, since)
+	s.replayed = append(s.replayed, ids)
+	return tools, nil
+}
+
+func (s *fakeSource) ToolIDs(context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id := range s.tools {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func TestIndexVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		ok      bool
+	}{
+		{name: "tools_v1", version: 1, ok: true},
+		{name: "tools_v12", version: 12, ok: true},
+		{name: "tools"},
+		{name: "tools_v0"},
+		{name: "tools_vx"},
+		{name: "tools_v-1"},
+		{name: "other_v3"},
+		{name: "tools_v1_old"},
+	}
+	for _, tt := range tests {
+		version, ok := indexVersion("tools", tt.name)
+		if version != tt.version || ok != tt.ok {
+			t.Errorf("indexVersion(%q) = %d, %v, want %d, %v", tt.name, version, ok, tt.version, tt.ok)
+		}
+	}
+	if got := versionedIndex("tools", 3); got != "tools_v3" {
+		t.Errorf("versionedIndex() = %q", got)
+	}
+}
+
+func TestReindex(t *testing.T) {
+	cluster := newFakeCluster()
+	cluster.indexes["tools_v1"] = map[string]json.RawMessage{"a": nil, "b": nil, "c": nil}
+	cluster.aliases["tools"] = []string{"tools_v1"}
+	client := newTestClient(t, cluster.ServeHTTP)
+
+	// Unchanged tools were last written an hour ago, well before the reindex
+	source := newFakeSource(time.Now().Add(-time.Hour), "a", "b", "c")
+	source.onAllTools = func() {
+		// Written while the new index is being built
+		source.write("b", "b renamed")
+		source.remove("c")
+		source.write("d", "d")
+	}
+	cluster.onRefresh = func() {
+		// Written after the first replay, before the alias moves
+		source.write("e", "e")
+	}
+
+	before := time.Now()
+	name, count, err := client.Reindex(context.Background(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "tools_v2" || count != 4 {
+		t.Errorf("Reindex() = %s, %d, want tools_v2, 4", name, count)
+	}
+	if got := cluster.aliases["tools"]; !reflect.DeepEqual(got, []string{"tools_v2"}) {
+		t.Errorf("alias points to %v, want [tools_v2]", got)
+	}
+	if got := cluster.docIDs("tools"); !reflect.DeepEqual(got, []string{"a", "b", "d", "e"}) {
+		t.Errorf("alias has %v, want [a b d e]", got)
+	}
+	var doc struct {
+		Name string `json:"name"`
+	}
+	json.Unmarshal(cluster.indexes["tools_v2"]["b"], &doc)
+	if doc.Name != "b renamed" {
+		t.Errorf("b has name %q, want the update made during the reindex", doc.Name)
+	}
+	if got := cluster.indexNames(); !reflect.DeepEqual(got, []string{"tools_v1", "tools_v2"}) {
+		t.Errorf("indexes = %v, want the previous version kept", got)
+	}
+
+	// The first replay covers everything written since the reindex started,
+	// widened by the margin; the second covers writes since the first replay
+	if len(source.since) != 2 {
+		t.Fatalf("replayed %d times, want 2", len(source.since))
+	}
+	if earliest := before.Add(-reindexReplayMargin); source.since[0].Before(earliest) {
+		t.Errorf("first replay since %v, want at most the margin before %v", source.since[0], before)
+	}
+	if !source.since[1].After(source.since[0]) {
+		t.Errorf("second replay since %v, not after the first at %v", source.since[1], source.since[0])
+	}
+	if got := source.replayed[0]; !reflect.DeepEqual(got, []string{"b", "d"}) {
+		t.Errorf("first replay = %v, want only the tools written during the build", got)
+	}
+	if got := source.replayed[1]; !reflect.DeepEqual(got, []string{"b", "d", "e"}) {
+		t.Errorf("second replay = %v, want [b d e]", got)
+	}
+}
+
+func TestReindexReplacesLegacyIndex(t *testing.T) {
+	cluster := newFakeCluster()
+	cluster.indexes["tools"] = map[string]json.RawMessage{"a": nil}
+	client := newTestClient(t, cluster.ServeHTTP)
+
+	name, _, err := client.Reindex(context.Background(), newFakeSource(time.Now(), "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "tools_v1" {
+		t.Errorf("Reindex() = %s, want tools_v1", name)
+	}
+	if got := cluster.indexNames(); !reflect.DeepEqual(got, []string{"tools_v1"}) {
+		t.Errorf("indexes = %v, want the legacy index replaced", got)
+	}
+	if got := cluster.aliases["tools"]; !reflect.DeepEqual(got, []string{"tools_v1"}) {
+		t.Errorf("alias points to %v, want [tools_v1]", got)
+	}
+}
+
+func TestReindexFailureKeepsAlias(t *testing.T) {
+	cluster := newFakeCluster()
+	cluster.indexes["tools_v1"] = map[string]json.RawMessage{"a": nil}
+	cluster.aliases["tools"] = []string{"tools_v1"}
+	cluster.failBulk = true
+	client := newTestClient(t, cluster.ServeHTTP)
+
+	if _, _, err := client.Reindex(context.Background(), newFakeSource(time.Now(), "a")); err == nil {
+		t.Fatal("expected the bulk failure to be returned")
+	}
+	if got := cluster.aliases["tools"]; !reflect.DeepEqual(got, []string{"tools_v1"}) {
+		t.Errorf("alias points to %v, want it left on tools_v1", got)
+	}
+	if got := cluster.indexNames(); !reflect.DeepEqual(got, []string{"tools_v1"}) {
+		t.Errorf("indexes = %v, want the partial index deleted", got)
+	}
+}
+
+func TestCleanupIndexes(t *testing.T) {
+	cluster := newFakeCluster()
+	for _, name := range []string{"tools_v1", "tools_v2", "tools_v3", "tools_v10", "other_v1"} {
+		cluster.indexes[name] = map[string]json.RawMessage{}
+	}
+	cluster.aliases["tools"] = []string{"tools_v3"}
+	client := newTestClient(t, cluster.ServeHTTP)
+
+	deleted, err := client.CleanupIndexes(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The live index is never deleted, and v10 is the newest previous version
+	if !reflect.DeepEqual(deleted, []string{"tools_v2", "tools_v1"}) {
+		t.Errorf("deleted %v, want [tools_v2 tools_v1]", deleted)
+	}
+	if got := cluster.indexNames(); !reflect.DeepEqual(got, []string{"other_v1", "tools_v10", "tools_v3"}) {
+		t.Errorf("indexes = %v", got)
+	}
+}
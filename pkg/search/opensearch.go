@@ -1,125 +1,246 @@
 package search
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
+	"smartsearch/pkg/config"
+	"smartsearch/pkg/filter"
+	"smartsearch/pkg/logging"
+	"smartsearch/pkg/metrics"
 	"smartsearch/pkg/models"
+	"smartsearch/pkg/schema"
+	"smartsearch/pkg/tracing"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/opensearch-project/opensearch-go"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type OpenSearchClient struct {
-	client *opensearch.Client
-	index  string
+	client   *opensearch.Client
+	index    string
+	analysis analysisOptions
+	logger   *slog.Logger
 }
 
-func NewOpenSearchClient(client *opensearch.Client, index string) *OpenSearchClient {
+func NewOpenSearchClient(client *opensearch.Client, index string, cfg *config.Config, logger *slog.Logger) *OpenSearchClient {
 	return &OpenSearchClient{
 		client: client,
 		index:  index,
+		analysis: analysisOptions{
+			stemmer:       cfg.OpenSearch.Analysis.Stemmer,
+			asciiFolding:  cfg.OpenSearch.Analysis.ASCIIFolding,
+			wordDelimiter: cfg.OpenSearch.Analysis.WordDelimiter,
+			synonymsFile:  cfg.OpenSearch.Analysis.SynonymsFile,
+		},
+		logger: logging.OrDefault(logger),
 	}
 }
 
-// CreateOrUpdateIndex creates or updates the index with proper settings
-func (c *OpenSearchClient) CreateOrUpdateIndex(ctx context.Context) error {
-	// Define index settings
-	settings := map[string]interface{}{
+// indexDefinition returns the settings and mappings a new tools index should have
+func (c *OpenSearchClient) indexDefinition() (map[string]interface{}, error) {
+	synonyms, err := loadSynonyms(c.analysis.synonymsFile)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
 		"settings": map[string]interface{}{
-			"index": map[string]interface{}{
-				"mapping": map[string]interface{}{
-					"total_fields.limit": 2000, // Increase field limit
+			"analysis": c.analysis.settings(synonyms),
+		},
+		"mappings": indexMappings(),
+	}, nil
+}
+
+// indexMappings returns the field mappings the tools index should have
+func indexMappings() map[string]interface{} {
+	return map[string]interface{}{
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":            "text",
+				"analyzer":        textAnalyzer,
+				"search_analyzer": searchAnalyzer,
+				"fields": map[string]interface{}{
+					"keyword": map[string]interface{}{
+						"type": "keyword",
+					},
 				},
 			},
-		},
-		"mappings": map[string]interface{}{
-			"properties": map[string]interface{}{
-				"name": map[string]interface{}{
-					"type": "text",
-					"fields": map[string]interface{}{
-						"keyword": map[string]interface{}{
-							"type": "keyword",
+			"description": map[string]interface{}{
+				"type":            "text",
+				"analyzer":        textAnalyzer,
+				"search_analyzer": searchAnalyzer,
+			},
+			"category": map[string]interface{}{
+				"type": "keyword",
+			},
+			"tags": map[string]interface{}{
+				"type": "keyword",
+			},
+			"version_key": map[string]interface{}{
+				"type": "keyword",
+			},
+			"created_at": map[string]interface{}{
+				"type": "date",
+			},
+			"updated_at": map[string]interface{}{
+				"type": "date",
+			},
+			// Schemas are returned with results but not indexed, so arbitrary
+			// schemas cannot grow the mapping; parameters holds the searchable part
+			"input_schema": map[string]interface{}{
+				"type":    "object",
+				"enabled": false,
+			},
+			"output_schema": map[string]interface{}{
+				"type":    "object",
+				"enabled": false,
+			},
+			"parameters": map[string]interface{}{
+				"type": "nested",
+				"properties": map[string]interface{}{
+					"direction": map[string]interface{}{
+						"type": "keyword",
+					},
+					"name": map[string]interface{}{
+						"type": "keyword",
+						"fields": map[string]interface{}{
+							"text": map[string]interface{}{
+								"type":            "text",
+								"analyzer":        textAnalyzer,
+								"search_analyzer": searchAnalyzer,
+							},
 						},
 					},
-				},
-				"description": map[string]interface{}{
-					"type": "text",
-				},
-				"category": map[string]interface{}{
-					"type": "keyword",
-				},
-				"tags": map[string]interface{}{
-					"type": "keyword",
+					"type": map[string]interface{}{
+						"type": "keyword",
+					},
+					"description": map[string]interface{}{
+						"type":            "text",
+						"analyzer":        textAnalyzer,
+						"search_analyzer": searchAnalyzer,
+					},
+					"required": map[string]interface{}{
+						"type": "boolean",
+					},
 				},
 			},
 		},
 	}
+}
 
-	// Convert settings to JSON
-	settingsJSON, err := json.Marshal(settings)
-	if err != nil {
-		return fmt.Errorf("failed to marshal index settings: %w", err)
-	}
-
-	// Check if index exists
-	exists, err := c.client.Indices.Exists([]string{c.index})
+// CreateOrUpdateIndex makes sure the index alias exists. On a fresh cluster it
+// creates the first versioned index behind the alias; otherwise it adds any new
+// fields to the live mapping. Changes that cannot be applied in place, such as
+// analyzers or field types, need Reindex.
+func (c *OpenSearchClient) CreateOrUpdateIndex(ctx context.Context) error {
+	targets, err := c.aliasTargets(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to check if index exists: %w", err)
+		return err
 	}
 
-	if exists.StatusCode == 404 {
-		// Create new index
-		res, err := c.client.Indices.Create(
-			c.index,
-			c.client.Indices.Create.WithContext(ctx),
-			c.client.Indices.Create.WithBody(strings.NewReader(string(settingsJSON))),
-		)
+	if len(targets) == 0 {
+		exists, err := c.client.Indices.Exists([]string{c.index}, c.client.Indices.Exists.WithContext(ctx))
 		if err != nil {
-			return fmt.Errorf("failed to create index: %w", err)
-		}
-		defer res.Body.Close()
-
-		if res.IsError() {
-			return fmt.Errorf("error creating index: %s", res.String())
+			return fmt.Errorf("failed to check if index exists: %w", err)
 		}
-	} else {
-		// Update existing index settings
-		res, err := c.client.Indices.PutSettings(
-			strings.NewReader(string(settingsJSON)),
-			c.client.Indices.PutSettings.WithContext(ctx),
-			c.client.Indices.PutSettings.WithIndex(c.index),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to update index settings: %w", err)
+		exists.Body.Close()
+
+		if exists.StatusCode == 404 {
+			name := versionedIndex(c.index, 1)
+			if err := c.createIndex(ctx, name, true); err != nil {
+				return err
+			}
+			c.logger.Info("created index", "index", name, "alias", c.index)
+			return nil
 		}
-		defer res.Body.Close()
 
-		if res.IsError() {
-			return fmt.Errorf("error updating index settings: %s", res.String())
-		}
+		// A concrete index from before versioning; Reindex replaces it with an alias
+		c.logger.Warn("index is not behind an alias, run reindex to migrate it", "index", c.index)
+	}
+
+	// Add any new fields to the existing mapping
+	mappingJSON, err := json.Marshal(indexMappings())
+	if err != nil {
+		return fmt.Errorf("failed to marshal index mappings: %w", err)
+	}
+	res, err := c.client.Indices.PutMapping(
+		strings.NewReader(string(mappingJSON)),
+		c.client.Indices.PutMapping.WithContext(ctx),
+		c.client.Indices.PutMapping.WithIndex(c.index),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update index mappings: %w", err)
+	}
+	defer res.Body.Close()
+
+	// Conflicting changes, such as a new analyzer on an existing field, only
+	// apply to a new index; the live one keeps serving until then
+	if res.StatusCode == 400 {
+		c.logger.Warn("index mapping cannot be updated in place, run reindex to apply it",
+			"index", c.index, "error", res.String())
+		return nil
+	}
+	if res.IsError() {
+		return fmt.Errorf("error updating index mappings: %s", res.String())
 	}
 
 	return nil
 }
 
-func (c *OpenSearchClient) Search(ctx context.Context, query string, k int) ([]models.SearchResult, error) {
-	// Construct search query
+// KeywordOptions adjusts a keyword search beyond the query text
+type KeywordOptions struct {
+	Filter        *filter.Filter // restricts the candidate tools
+	ExpandedTerms []string       // related terms that can add matches at a lower weight
+	Explain       bool           // attach OpenSearch's score explanation to each result
+}
+
+// expandedTermsBoost weights matches on expanded terms relative to the original query
+const expandedTermsBoost = 0.5
+
+// parameterBoost weights matches on schema parameters relative to tool text
+const parameterBoost = 0.5
+
+func (c *OpenSearchClient) Search(ctx context.Context, query string, k int, opts KeywordOptions) (results []models.SearchResult, err error) {
+	start := time.Now()
+	defer metrics.ObserveStage(metrics.StageOpenSearch, start)
+
+	ctx, span := tracing.Start(ctx, "opensearch.search",
+		attribute.String("opensearch.index", c.index),
+		attribute.Int("search.k", k),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("search.results", len(results)))
+		tracing.End(span, err)
+	}()
+
+	// Construct search query; filters run in filter context so they do not affect scoring
+	should := []map[string]interface{}{multiMatch(query, 1), parameterMatch(query, parameterBoost)}
+	if len(opts.ExpandedTerms) > 0 {
+		// Expanded terms can match on their own, but score lower than the original query
+		should = append(should, multiMatch(strings.Join(opts.ExpandedTerms, " "), expandedTermsBoost))
+	}
+	boolQuery := map[string]interface{}{
+		"should":               should,
+		"minimum_should_match": 1,
+	}
+	if clauses := opts.Filter.OpenSearch(); len(clauses) > 0 {
+		boolQuery["filter"] = clauses
+	}
+
 	searchQuery := map[string]interface{}{
 		"query": map[string]interface{}{
-			"multi_match": map[string]interface{}{
-				"query":     query,
-				"fields":    []string{"name^3", "description^2", "category", "tags"},
-				"type":      "best_fields",
-				"tie_breaker": 0.3,
-			},
+			"bool": boolQuery,
 		},
-		"size": k,
+		"size":             k,
 		"track_total_hits": true,
 	}
+	if opts.Explain {
+		searchQuery["explain"] = true
+	}
 
 	// Convert query to JSON
 	queryJSON, err := json.Marshal(searchQuery)
@@ -127,7 +248,8 @@ func (c *OpenSearchClient) Search(ctx context.Context, query string, k int) ([]m
 		return nil, fmt.Errorf("failed to marshal search query: %w", err)
 	}
 
-	log.Printf("OpenSearch query: %s", string(queryJSON))
+	logger := logging.FromContext(ctx, c.logger)
+	logger.Debug("keyword search", logging.QueryKey, query, "k", k)
 
 	// Execute search
 	res, err := c.client.Search(
@@ -136,19 +258,16 @@ func (c *OpenSearchClient) Search(ctx context.Context, query string, k int) ([]m
 		c.client.Search.WithBody(strings.NewReader(string(queryJSON))),
 	)
 	if err != nil {
+		metrics.DependencyError(metrics.DependencyOpenSearch)
 		return nil, fmt.Errorf("failed to execute search: %w", err)
 	}
 	defer res.Body.Close()
 
-	// Read and log the raw response
-	bodyBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	// Error responses decode to zero hits, so they must fail the leg instead
+	if res.IsError() {
+		metrics.DependencyError(metrics.DependencyOpenSearch)
+		return nil, fmt.Errorf("error executing search: %s", res.String())
 	}
-	log.Printf("OpenSearch raw response: %s", string(bodyBytes))
-
-	// Create a new reader for the response body since we consumed it
-	res.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 
 	// Parse response
 	var searchResponse struct {
@@ -157,8 +276,9 @@ func (c *OpenSearchClient) Search(ctx context.Context, query string, k int) ([]m
 				Value int `json:"value"`
 			} `json:"total"`
 			Hits []struct {
-				Source models.Tool `json:"_source"`
-				Score  float64    `json:"_score"`
+				Source      models.Tool     `json:"_source"`
+				Score       float64         `json:"_score"`
+				Explanation json.RawMessage `json:"_explanation"`
 			} `json:"hits"`
 		} `json:"hits"`
 	}
@@ -167,25 +287,94 @@ func (c *OpenSearchClient) Search(ctx context.Context, query string, k int) ([]m
 		return nil, fmt.Errorf("failed to decode search response: %w", err)
 	}
 
-	log.Printf("OpenSearch found %d total hits", len(searchResponse.Hits.Hits))
+	logger.Debug("keyword search results", "count", len(searchResponse.Hits.Hits))
 
 	// Convert to SearchResult slice
-	results := make([]models.SearchResult, 0, len(searchResponse.Hits.Hits))
-	for _, hit := range searchResponse.Hits.Hits {
-		log.Printf("Found tool: %s with score: %f", hit.Source.Name, hit.Score)
-		results = append(results, models.SearchResult{
+	results = make([]models.SearchResult, 0, len(searchResponse.Hits.Hits))
+	for i, hit := range searchResponse.Hits.Hits {
+		logger.Debug("keyword hit", "tool_id", hit.Source.ID, "score", hit.Score)
+		result := models.SearchResult{
 			Tool:         hit.Source,
 			Score:        hit.Score,
 			KeywordScore: hit.Score,
-		})
+		}
+		if opts.Explain {
+			result.Explanation = &models.Explanation{Keyword: &models.LegExplanation{
+				Rank:    i + 1,
+				Score:   hit.Score,
+				Details: hit.Explanation,
+			}}
+		}
+		results = append(results, result)
 	}
 
 	return results, nil
 }
 
+// toolDocument is the OpenSearch representation of a tool
+type toolDocument struct {
+	models.Tool
+	VersionKey string              `json:"version_key"`
+	Parameters []parameterDocument `json:"parameters"`
+}
+
+// parameterDocument is one flattened input or output schema parameter
+type parameterDocument struct {
+	Direction string `json:"direction"`
+	schema.Property
+}
+
+func newToolDocument(tool models.Tool) toolDocument {
+	params := []parameterDocument{}
+	for _, prop := range schema.Flatten(tool.InputSchema) {
+		params = append(params, parameterDocument{Direction: filter.DirectionInput, Property: prop})
+	}
+	for _, prop := range schema.Flatten(tool.OutputSchema) {
+		params = append(params, parameterDocument{Direction: filter.DirectionOutput, Property: prop})
+	}
+
+	return toolDocument{
+		Tool:       tool,
+		VersionKey: filter.VersionKey(tool.Version),
+		Parameters: params,
+	}
+}
+
+// multiMatch builds the weighted multi_match clause used for tool text fields
+func multiMatch(query string, boost float64) map[string]interface{} {
+	return map[string]interface{}{
+		"multi_match": map[string]interface{}{
+			"query":       query,
+			"fields":      []string{"name^3", "description^2", "category", "tags"},
+			"type":        "best_fields",
+			"tie_breaker": 0.3,
+			"boost":       boost,
+		},
+	}
+}
+
+// parameterMatch matches the query against schema parameter names and
+// descriptions, scoring a tool by its best parameter
+func parameterMatch(query string, boost float64) map[string]interface{} {
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path": "parameters",
+			"query": map[string]interface{}{
+				"multi_match": map[string]interface{}{
+					"query":  query,
+					"fields": []string{"parameters.name.text^2", "parameters.description"},
+				},
+			},
+			"score_mode":      "max",
+			"boost":           boost,
+			"ignore_unmapped": true, // indexes built before parameters were added
+		},
+	}
+}
+
 func (c *OpenSearchClient) IndexTool(ctx context.Context, tool models.Tool) error {
 	// Convert tool to JSON
-	toolJSON, err := json.Marshal(tool)
+	toolJSON, err := json.Marshal(newToolDocument(tool))
 	if err != nil {
 		return fmt.Errorf("failed to marshal tool: %w", err)
 	}
@@ -210,8 +399,13 @@ func (c *OpenSearchClient) IndexTool(ctx context.Context, tool models.Tool) erro
 }
 
 func (c *OpenSearchClient) DeleteTool(ctx context.Context, toolID string) error {
+	return c.deleteFrom(ctx, c.index, toolID)
+}
+
+// deleteFrom removes a tool from one index or alias
+func (c *OpenSearchClient) deleteFrom(ctx context.Context, index, toolID string) error {
 	res, err := c.client.Delete(
-		c.index,
+		index,
 		toolID,
 		c.client.Delete.WithContext(ctx),
 	)
@@ -220,6 +414,11 @@ func (c *OpenSearchClient) DeleteTool(ctx context.Context, toolID string) error
 	}
 	defer res.Body.Close()
 
+	// A missing document is already deleted
+	if res.StatusCode == 404 {
+		return nil
+	}
+
 	if res.IsError() {
 		return fmt.Errorf("error deleting tool: %s", res.String())
 	}
@@ -227,51 +426,110 @@ func (c *OpenSearchClient) DeleteTool(ctx context.Context, toolID string) error
 	return nil
 }
 
-// VerifyIndexSettings checks if the index exists and has the correct settings
-func (c *OpenSearchClient) VerifyIndexSettings(ctx context.Context) error {
-	// Check if index exists
-	exists, err := c.client.Indices.Exists([]string{c.index})
-	if err != nil {
-		return fmt.Errorf("failed to check if index exists: %w", err)
-	}
-
-	if exists.StatusCode == 404 {
-		return fmt.Errorf("index %s does not exist", c.index)
+// MappingDrift compares the live index mapping against indexMappings and
+// describes every expected field that is missing or configured differently.
+// Fields present only in the live mapping are not drift.
+func (c *OpenSearchClient) MappingDrift(ctx context.Context) ([]string, error) {
+	if err := c.CheckIndex(ctx); err != nil {
+		return nil, err
 	}
 
-	// Get index settings
-	res, err := c.client.Indices.GetSettings(
-		c.client.Indices.GetSettings.WithContext(ctx),
-		c.client.Indices.GetSettings.WithIndex(c.index),
+	res, err := c.client.Indices.GetMapping(
+		c.client.Indices.GetMapping.WithContext(ctx),
+		c.client.Indices.GetMapping.WithIndex(c.index),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to get index settings: %w", err)
+		return nil, fmt.Errorf("failed to get index mappings: %w", err)
 	}
 	defer res.Body.Close()
 
-	// Read and log the settings
-	bodyBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read settings response: %w", err)
+	if res.IsError() {
+		return nil, fmt.Errorf("error getting index mappings: %s", res.String())
 	}
-	log.Printf("Index settings: %s", string(bodyBytes))
 
-	// Get index mappings
-	res, err = c.client.Indices.GetMapping(
-		c.client.Indices.GetMapping.WithContext(ctx),
-		c.client.Indices.GetMapping.WithIndex(c.index),
-	)
+	// The response is keyed by concrete index name
+	var live map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&live); err != nil {
+		return nil, fmt.Errorf("failed to decode index mappings: %w", err)
+	}
+
+	expected := indexMappings()
+	var drift []string
+	for name, index := range live {
+		for _, d := range compareMapping(expected, index.Mappings, "") {
+			drift = append(drift, name+": "+d)
+		}
+	}
+	sort.Strings(drift)
+	return drift, nil
+}
+
+// compareMapping reports values in expected that differ from or are missing in live
+func compareMapping(expected, live map[string]interface{}, path string) []string {
+	var drift []string
+	for key, want := range expected {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+
+		got, ok := live[key]
+		if !ok {
+			drift = append(drift, fieldPath+" is missing")
+			continue
+		}
+
+		if wantMap, isMap := want.(map[string]interface{}); isMap {
+			gotMap, _ := got.(map[string]interface{})
+			drift = append(drift, compareMapping(wantMap, gotMap, fieldPath)...)
+			continue
+		}
+		if fmt.Sprint(want) != fmt.Sprint(got) {
+			drift = append(drift, fmt.Sprintf("%s is %v, expected %v", fieldPath, got, want))
+		}
+	}
+	return drift
+}
+
+// Ping checks that the cluster is reachable and not in red health
+func (c *OpenSearchClient) Ping(ctx context.Context) error {
+	res, err := c.client.Cluster.Health(c.client.Cluster.Health.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("failed to get index mappings: %w", err)
+		return fmt.Errorf("failed to get cluster health: %w", err)
 	}
 	defer res.Body.Close()
 
-	// Read and log the mappings
-	bodyBytes, err = io.ReadAll(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("error getting cluster health: %s", res.String())
+	}
+
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return fmt.Errorf("failed to decode cluster health: %w", err)
+	}
+	if health.Status == "red" {
+		return fmt.Errorf("cluster health is red")
+	}
+	return nil
+}
+
+// CheckIndex checks that the tools index or alias exists
+func (c *OpenSearchClient) CheckIndex(ctx context.Context) error {
+	res, err := c.client.Indices.Exists([]string{c.index}, c.client.Indices.Exists.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("failed to read mappings response: %w", err)
+		return fmt.Errorf("failed to check if index exists: %w", err)
 	}
-	log.Printf("Index mappings: %s", string(bodyBytes))
+	defer res.Body.Close()
 
+	if res.StatusCode == 404 {
+		return fmt.Errorf("index %s does not exist", c.index)
+	}
+	if res.IsError() {
+		return fmt.Errorf("error checking index: %s", res.String())
+	}
 	return nil
-} 
\ No newline at end of file
+}
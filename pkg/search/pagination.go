@@ -0,0 +1,14 @@
+package search
+
+import "smartsearch/pkg/models"
+
+// paginate splits a ranking into the page at offset and the results after it,
+// stopping at the result window
+func paginate(results []models.SearchResult, offset, pageSize, window int) (page, rest []models.SearchResult) {
+	if window < len(results) {
+		results = results[:window]
+	}
+	start := min(offset, len(results))
+	end := min(offset+pageSize, len(results))
+	return results[start:end], results[end:]
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"fmt"
+	"smartsearch/pkg/models"
+	"testing"
+)
+
+// ranking builds n results with IDs r0, r1, ... in rank order
+func ranking(n int) []models.SearchResult {
+	results := make([]models.SearchResult, n)
+	for i := range results {
+		results[i] = models.SearchResult{Tool: models.Tool{ID: fmt.Sprintf("r%d", i)}, Score: 1 - float64(i)/100}
+	}
+	return results
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name                    string
+		n, offset, size, window int
+		wantPage, wantRest      string
+	}{
+		{name: "first page", n: 5, offset: 0, size: 2, window: 100, wantPage: "r0,r1", wantRest: "r2,r3,r4"},
+		{name: "middle page", n: 5, offset: 2, size: 2, window: 100, wantPage: "r2,r3", wantRest: "r4"},
+		{name: "last partial page", n: 5, offset: 4, size: 2, window: 100, wantPage: "r4", wantRest: ""},
+		{name: "past the end", n: 5, offset: 7, size: 2, window: 100, wantPage: "", wantRest: ""},
+		{name: "window cuts rest", n: 10, offset: 0, size: 2, window: 4, wantPage: "r0,r1", wantRest: "r2,r3"},
+		{name: "window cuts page", n: 10, offset: 3, size: 2, window: 4, wantPage: "r3", wantRest: ""},
+		{name: "empty", n: 0, offset: 0, size: 5, window: 100, wantPage: "", wantRest: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, rest := paginate(ranking(tt.n), tt.offset, tt.size, tt.window)
+			if ids(page) != tt.wantPage || ids(rest) != tt.wantRest {
+				t.Errorf("paginate = [%s] [%s], want [%s] [%s]", ids(page), ids(rest), tt.wantPage, tt.wantRest)
+			}
+		})
+	}
+}
+
+// Walking a ranking page by page through cursors visits every result once
+func TestCursorPaging(t *testing.T) {
+	full := ranking(7)
+
+	page, rest := paginate(full, 0, 3, len(full))
+	seen := ids(page)
+	offset := 3
+	for len(rest) > 0 {
+		cursor, err := newCursor(testCursorKey, "req", offset, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state, err := decodeCursor(testCursorKey, cursor, "req")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Offset != offset || state.PageSize != 3 {
+			t.Errorf("cursor offset %d page size %d, want %d 3", state.Offset, state.PageSize, offset)
+		}
+		page, rest = paginate(full, state.Offset, state.PageSize, len(full))
+		seen += "," + ids(page)
+		offset += len(page)
+	}
+
+	if want := ids(full); seen != want {
+		t.Errorf("pages visited %s, want %s", seen, want)
+	}
+}
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"fmt"
+	"slices"
+	"smartsearch/pkg/models"
+	"strings"
+)
+
+// defaultFields is the compact projection returned when a request selects none
+var defaultFields = []string{"id", "name", "description"}
+
+// resolveFields validates the requested tool fields and returns the projection
+// to apply, or nil for every field. The tool ID is always included.
+func resolveFields(selection models.FieldSelection) ([]string, error) {
+	if len(selection) == 0 {
+		return defaultFields, nil
+	}
+	if len(selection) == 1 && selection[0] == models.FieldsFull {
+		return nil, nil
+	}
+
+	fields := []string{"id"}
+	var unknown []string
+	for _, field := range selection {
+		switch {
+		case !slices.Contains(models.ToolFields, field):
+			unknown = append(unknown, field)
+		case !slices.Contains(fields, field):
+			fields = append(fields, field)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: unknown fields %s, expected %q or any of %s",
+			ErrInvalidRequest, strings.Join(unknown, ", "), models.FieldsFull, strings.Join(models.ToolFields, ", "))
+	}
+	return fields, nil
+}
+
+// project applies the field projection to every result
+func project(results []models.SearchResult, fields []string) []models.SearchResult {
+	for i := range results {
+		results[i].Fields = fields
+	}
+	return results
+}
@@ -0,0 +1,42 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"smartsearch/pkg/models"
+	"testing"
+)
+
+func TestResolveFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection models.FieldSelection
+		want      []string
+		wantErr   bool
+	}{
+		{name: "default", selection: nil, want: defaultFields},
+		{name: "full", selection: models.FieldSelection{models.FieldsFull}, want: nil},
+		{name: "id always included", selection: models.FieldSelection{"tags"}, want: []string{"id", "tags"}},
+		{name: "duplicates removed", selection: models.FieldSelection{"name", "id", "name"}, want: []string{"id", "name"}},
+		{name: "unknown field", selection: models.FieldSelection{"name", "owner"}, wantErr: true},
+		{name: "full among others", selection: models.FieldSelection{"full", "name"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveFields(tt.selection)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRequest) {
+					t.Errorf("error = %v, want ErrInvalidRequest", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveFields(%v) = %v, want %v", tt.selection, got, tt.want)
+			}
+		})
+	}
+}
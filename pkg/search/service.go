@@ -2,155 +2,554 @@ package search
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"smartsearch/internal/query"
+	"smartsearch/internal/rerank"
 	"smartsearch/internal/vector"
+	"smartsearch/pkg/config"
+	"smartsearch/pkg/filter"
+	"smartsearch/pkg/logging"
+	"smartsearch/pkg/metrics"
 	"smartsearch/pkg/models"
-	"sort"
+	"smartsearch/pkg/tracing"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	defaultTopK                = 10
+	defaultMaxTopK             = 100
+	defaultCandidateMultiplier = 2
+	defaultMaxResultWindow     = 1000
+	defaultUnderstandTimeout   = 10 * time.Second
+	defaultMaxSubQueries       = 3
+	defaultRerankTopN          = 10
+	defaultRerankTimeout       = 15 * time.Second
+)
+
+// ErrInvalidRequest wraps errors caused by a malformed search request
+var ErrInvalidRequest = errors.New("invalid search request")
+
 type Service struct {
 	queryEngine  *query.QueryEngine
 	vectorStore  *vector.VectorStore
 	searchClient *OpenSearchClient
+	reranker     *rerank.Reranker
+	logger       *slog.Logger
+
+	// processCursorKey signs cursors when search.cursor_secret is empty
+	processCursorKey []byte
+
+	// tuning is swapped as a whole on config reload; each request loads it
+	// once so it sees a consistent set of settings
+	tuning atomic.Pointer[tuning]
+}
+
+// tuning holds the search settings that can change without a restart
+type tuning struct {
+	defaultTopK         int
+	maxTopK             int
+	candidateMultiplier int
+	// defaultMinScore overrides the fusion strategy's MinScore when set
+	defaultMinScore float64
+
+	fusers       map[string]Fuser
+	defaultFuser Fuser
+
+	understandEnabled bool
+	understandTimeout time.Duration
+	maxSubQueries     int
+
+	rerankEnabled  bool
+	rerankTopN     int
+	rerankTimeout  time.Duration
+	rerankFallback bool
+
+	maxResultWindow int
+	cursorKey       []byte
+
+	// pgvector index tuning, overridable per request
+	efSearch      int
+	probes        int
+	iterativeScan string
+
+	vectorTimeout  time.Duration
+	keywordTimeout time.Duration
+	failureMode    string
 }
 
 func NewService(
 	queryEngine *query.QueryEngine,
 	vectorStore *vector.VectorStore,
 	searchClient *OpenSearchClient,
-) *Service {
-	return &Service{
+	reranker *rerank.Reranker,
+	cfg *config.Config,
+	logger *slog.Logger,
+) (*Service, error) {
+	s := &Service{
 		queryEngine:  queryEngine,
 		vectorStore:  vectorStore,
 		searchClient: searchClient,
+		reranker:     reranker,
+		logger:       logging.OrDefault(logger),
+	}
+	s.processCursorKey = make([]byte, 32)
+	if _, err := rand.Read(s.processCursorKey); err != nil {
+		return nil, fmt.Errorf("failed to generate cursor key: %w", err)
+	}
+	if cfg.Search.CursorSecret == "" {
+		s.logger.Warn("search.cursor_secret is not set, cursors only work on this process until it restarts")
 	}
+	s.Reload(cfg)
+	return s, nil
 }
 
-func (s *Service) Search(ctx context.Context, req models.SearchRequest) (*models.SearchResponse, error) {
-	startTime := time.Now()
+// Reload applies the tunable search settings from cfg. Requests already in
+// flight finish with the settings they started with.
+func (s *Service) Reload(cfg *config.Config) {
+	s.tuning.Store(newTuning(cfg, s.reranker != nil, s.logger))
+}
 
-	// Verify OpenSearch index settings
-	if err := s.searchClient.VerifyIndexSettings(ctx); err != nil {
-		log.Printf("Warning: OpenSearch index verification failed: %v", err)
+func newTuning(cfg *config.Config, hasReranker bool, logger *slog.Logger) *tuning {
+	t := &tuning{
+		defaultTopK:         cfg.Search.DefaultTopK,
+		maxTopK:             cfg.Search.MaxTopK,
+		candidateMultiplier: cfg.Search.CandidateMultiplier,
+		defaultMinScore:     cfg.Search.DefaultMinScore,
+		maxResultWindow:     cfg.Search.MaxResultWindow,
+		efSearch:            cfg.Search.EfSearch,
+		probes:              cfg.Search.IVFFlatProbes,
+		iterativeScan:       cfg.Search.IterativeScan,
+		understandEnabled:   cfg.QueryUnderstanding.Enabled,
+		understandTimeout:   time.Duration(cfg.QueryUnderstanding.TimeoutMS) * time.Millisecond,
+		maxSubQueries:       cfg.QueryUnderstanding.MaxSubQueries,
+		rerankEnabled:       cfg.Rerank.Enabled && hasReranker,
+		rerankTopN:          cfg.Rerank.TopN,
+		rerankTimeout:       time.Duration(cfg.Rerank.TimeoutMS) * time.Millisecond,
+		rerankFallback:      cfg.Rerank.Fallback,
+		vectorTimeout:       time.Duration(cfg.Search.VectorTimeoutMS) * time.Millisecond,
+		keywordTimeout:      time.Duration(cfg.Search.KeywordTimeoutMS) * time.Millisecond,
+		failureMode:         cfg.Search.FailureMode,
+	}
+	if t.defaultTopK <= 0 {
+		t.defaultTopK = defaultTopK
+	}
+	if t.maxTopK <= 0 {
+		t.maxTopK = defaultMaxTopK
+	}
+	if t.candidateMultiplier <= 0 {
+		t.candidateMultiplier = defaultCandidateMultiplier
+	}
+	if t.maxResultWindow <= 0 {
+		t.maxResultWindow = defaultMaxResultWindow
+	}
+	if cfg.Search.CursorSecret != "" {
+		t.cursorKey = []byte(cfg.Search.CursorSecret)
 	}
 
-	// Calculate k value
-	k := req.TopK * 2
-	if k < 1 {
-		k = 10
+	// Build every fusion strategy so requests can select one by name
+	weights := FusionWeights{Vector: cfg.Fusion.VectorWeight, Keyword: cfg.Fusion.KeywordWeight}
+	t.fusers = make(map[string]Fuser)
+	for _, name := range []string{FusionRRF, FusionMinMax, FusionZScore, FusionCombMNZ} {
+		fuser, _ := NewFuser(name, weights, cfg.Fusion.RRFK)
+		t.fusers[name] = fuser
+	}
+	t.defaultFuser = t.fusers[cfg.Fusion.Strategy]
+	if t.defaultFuser == nil {
+		if cfg.Fusion.Strategy != "" {
+			logger.Warn("unknown fusion strategy, using default", "strategy", cfg.Fusion.Strategy, "default", FusionRRF)
+		}
+		t.defaultFuser = t.fusers[FusionRRF]
 	}
 
-	// Create error group for parallel execution
-	g, ctx := errgroup.WithContext(ctx)
-	var vectorResults, keywordResults []models.SearchResult
+	if t.understandTimeout <= 0 {
+		t.understandTimeout = defaultUnderstandTimeout
+	}
+	if t.maxSubQueries <= 0 {
+		t.maxSubQueries = defaultMaxSubQueries
+	}
+	if t.rerankTopN <= 0 {
+		t.rerankTopN = defaultRerankTopN
+	}
+	if t.rerankTimeout <= 0 {
+		t.rerankTimeout = defaultRerankTimeout
+	}
+	if t.vectorTimeout <= 0 {
+		t.vectorTimeout = defaultVectorTimeout
+	}
+	if t.keywordTimeout <= 0 {
+		t.keywordTimeout = defaultKeywordTimeout
+	}
+	if t.failureMode == "" {
+		t.failureMode = FailureModeBestEffort
+	}
+	return t
+}
 
-	// Run vector search
-	g.Go(func() error {
-		results, err := s.vectorStore.Search(ctx, req.Query, k)
-		if err != nil {
-			return fmt.Errorf("vector search failed: %w", err)
+func (s *Service) Search(ctx context.Context, req models.SearchRequest) (*models.SearchResponse, error) {
+	return s.search(ctx, req, nil)
+}
+
+func (s *Service) search(ctx context.Context, req models.SearchRequest, emit func(event string, data any)) (resp *models.SearchResponse, err error) {
+	startTime := time.Now()
+	t := s.tuning.Load()
+
+	ctx, span := tracing.Start(ctx, "search.Search",
+		attribute.Int("search.top_k", req.TopK),
+		attribute.Bool("search.cursor", req.Cursor != ""),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Debug("search", logging.QueryKey, req.Query, "top_k", req.TopK)
+
+	// Resolve the tool fields to return
+	fields, err := resolveFields(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// Select the fusion strategy
+	fuser := t.defaultFuser
+	if req.Fusion != "" {
+		fuser = t.fusers[req.Fusion]
+		if fuser == nil {
+			return nil, fmt.Errorf("%w: unknown fusion strategy %q", ErrInvalidRequest, req.Fusion)
 		}
-		vectorResults = results
-		return nil
-	})
+	}
 
-	// Run keyword search
-	g.Go(func() error {
-		results, err := s.searchClient.Search(ctx, req.Query, k)
+	// Resolve the page; top_k is the page size when page_size is not given.
+	// A cursor supplies the offset, and its page size unless one is given.
+	fingerprint, err := requestFingerprint(req, fuser.Name(), fields)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 {
+		pageSize = req.TopK
+	}
+	offset := req.Offset
+	if req.Cursor != "" {
+		state, err := decodeCursor(s.cursorKey(t), req.Cursor, fingerprint)
 		if err != nil {
-			return fmt.Errorf("keyword search failed: %w", err)
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		offset = state.Offset
+		if pageSize < 1 {
+			pageSize = state.PageSize
 		}
-		keywordResults = results
-		return nil
-	})
+	}
+	if pageSize < 1 {
+		pageSize = t.defaultTopK
+	}
+	if pageSize > t.maxTopK {
+		pageSize = t.maxTopK
+	}
+	if req.Cursor != "" && offset+pageSize > t.maxResultWindow {
+		// Cursors only point inside the window, so the last page is cut short
+		pageSize = max(t.maxResultWindow-offset, 1)
+	}
+	if offset+pageSize > t.maxResultWindow {
+		return nil, fmt.Errorf("%w: offset + page_size must not exceed %d", ErrInvalidRequest, t.maxResultWindow)
+	}
 
-	// Wait for both searches to complete
+	// Each leg retrieves enough candidates to fill the page after fusion
+	candidates := min((offset+pageSize)*t.candidateMultiplier, t.maxResultWindow)
+
+	// Collect explanations when the request asks for them
+	ex := newExplainer(req)
+
+	// Run query understanding and merge its filters with the user's
+	planStart := time.Now()
+	plan, err := s.planQuery(ctx, t, req)
+	if err != nil {
+		return nil, err
+	}
+	ex.observe(stageUnderstanding, planStart)
+
+	// Run the main query and every sub-query as independent hybrid searches
+	g, gctx := errgroup.WithContext(ctx)
+	queries := append([]string{req.Query}, plan.subQueries...)
+	ex.plan(queries, fuser, candidates)
+	perQuery := make([][]models.SearchResult, len(queries))
+	perQueryLegs := make([]map[string]models.LegStatus, len(queries))
+	for i, q := range queries {
+		g.Go(func() error {
+			results, legs, err := s.hybridSearch(gctx, t, q, plan.expandedTerms, candidates, plan.filter, fuser, req, ex)
+			if err != nil {
+				return err
+			}
+			perQuery[i] = results
+			perQueryLegs[i] = legs
+			return nil
+		})
+	}
 	if err := g.Wait(); err != nil {
 		return nil, err
 	}
 
-	// Merge and deduplicate results
-	mergedResults := s.mergeResults(vectorResults, keywordResults)
-	log.Printf("Merged results count: %d", len(mergedResults))
+	// Report each leg's outcome across all queries
+	legs := make(map[string]models.LegStatus)
+	degraded := false
+	for _, queryLegs := range perQueryLegs {
+		for leg, status := range queryLegs {
+			legs[leg] = mergeLegStatus(legs[leg], status)
+			degraded = degraded || status.Status != models.LegOK
+		}
+	}
+
+	// Fuse sub-query results into a single ranking
+	fuseStart := time.Now()
+	mergedResults := fuseQueryResults(perQuery)
+	metrics.ObserveStage(metrics.StageFusion, fuseStart)
+	ex.observe(stageMerge, fuseStart)
+	metrics.ObserveResults(metrics.ResultsFused, len(mergedResults))
+	logger.Debug("merged results", "count", len(mergedResults))
 
 	// Apply minimum score threshold
 	minScore := req.MinScore
 	if minScore <= 0 {
-		minScore = 0.3 // Default minimum score threshold
+		minScore = t.defaultMinScore
 	}
+	if minScore <= 0 {
+		minScore = fuser.MinScore()
+	}
+	thresholdStart := time.Now()
 	finalResults := s.applyScoreThreshold(mergedResults, minScore)
-	log.Printf("Results after score threshold: %d", len(finalResults))
-
-	// Limit to top K results
-	if len(finalResults) > k {
-		finalResults = finalResults[:k]
-	}
-
-	// Create simplified results with only required fields
-	simplifiedResults := make([]models.SearchResult, len(finalResults))
-	for i, result := range finalResults {
-		simplifiedResults[i] = models.SearchResult{
-			Tool: models.Tool{
-				ID:          result.Tool.ID,
-				Name:        result.Tool.Name,
-				Description: result.Tool.Description,
-			},
-			Score:         result.Score,
-			VectorScore:   result.VectorScore,
-			KeywordScore:  result.KeywordScore,
-			RerankedScore: result.RerankedScore,
+	ex.threshold(mergedResults, minScore)
+	ex.observe(stageThreshold, thresholdStart)
+	total := len(finalResults)
+	logger.Debug("results after score threshold", "count", total, "min_score", minScore)
+
+	// Send the fused page as soon as it is known, before any reranking
+	if emit != nil {
+		page, _ := paginate(finalResults, offset, pageSize, t.maxResultWindow)
+		emit(EventFused, &models.SearchResponse{
+			Results:       project(page, fields),
+			Total:         total,
+			Offset:        offset,
+			PageSize:      pageSize,
+			Time:          float64(time.Since(startTime).Milliseconds()),
+			Degraded:      degraded,
+			Legs:          legs,
+			Understanding: plan.understanding,
+		})
+	}
+
+	// Rerank the head of the fused list
+	if s.shouldRerank(t, req) {
+		var onRanking func(rerank.Ranking)
+		if emit != nil {
+			onRanking = func(ranking rerank.Ranking) { emit(EventRerank, ranking) }
 		}
+
+		rerankStart := time.Now()
+		reranked, err := s.rerank(ctx, t, req.Query, finalResults, ex, onRanking)
+		if err != nil {
+			return nil, err
+		}
+		finalResults = reranked
+		ex.observe(stageRerank, rerankStart)
 	}
 
+	// Cut out the page; the cursor points the next request at the page after it
+	page, rest := paginate(finalResults, offset, pageSize, t.maxResultWindow)
+	var nextCursor string
+	if len(rest) > 0 {
+		nextCursor, err = newCursor(s.cursorKey(t), fingerprint, offset+pageSize, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
+
+	metrics.ObserveResults(metrics.ResultsFinal, len(page))
+	span.SetAttributes(
+		attribute.Int("search.offset", offset),
+		attribute.Int("search.page_size", pageSize),
+		attribute.Int("search.sub_queries", len(plan.subQueries)),
+		attribute.Int("search.results", len(page)),
+		attribute.Bool("search.degraded", degraded),
+	)
+
 	return &models.SearchResponse{
-		Results: simplifiedResults,
-		Total:   len(simplifiedResults),
-		Time:    float64(time.Since(startTime).Milliseconds()),
+		Results:       project(page, fields),
+		Total:         total,
+		Offset:        offset,
+		PageSize:      pageSize,
+		Time:          float64(time.Since(startTime).Milliseconds()),
+		NextCursor:    nextCursor,
+		Degraded:      degraded,
+		Legs:          legs,
+		Understanding: plan.understanding,
+		Explain:       ex.finish(startTime),
 	}, nil
 }
 
-func (s *Service) mergeResults(vectorResults, keywordResults []models.SearchResult) []models.SearchResult {
-	// Create map to track seen tools
-	seen := make(map[string]models.SearchResult)
-	
-	// Process vector results first
-	for _, result := range vectorResults {
-		result.VectorScore = result.Score // Store vector score separately
-		seen[result.Tool.ID] = result
-	}
-	
-	// Merge keyword results
-	for _, result := range keywordResults {
-		if existing, ok := seen[result.Tool.ID]; ok {
-			// Update scores if tool was found in both searches
-			existing.KeywordScore = result.Score
-			// Combine scores with 70% weight to vector and 30% to keyword
-			existing.Score = (existing.VectorScore * 0.7) + (result.Score * 0.3)
-			seen[result.Tool.ID] = existing
-		} else {
-			result.KeywordScore = result.Score
-			result.VectorScore = 0
-			seen[result.Tool.ID] = result
+// hybridSearch runs the vector and keyword legs for a single query in parallel,
+// each under its own deadline, and merges their results. In best-effort mode a
+// single failed leg is left out and reported in the returned leg statuses.
+func (s *Service) hybridSearch(
+	ctx context.Context,
+	t *tuning,
+	query string,
+	expandedTerms []string,
+	candidates int,
+	flt *filter.Filter,
+	fuser Fuser,
+	req models.SearchRequest,
+	ex *explainer,
+) ([]models.SearchResult, map[string]models.LegStatus, error) {
+	var wg sync.WaitGroup
+	var vectorResults, keywordResults []models.SearchResult
+	var vectorErr, keywordErr error
+	wg.Add(2)
+
+	// Run vector search
+	go func() {
+		defer wg.Done()
+		ctx, span := tracing.Start(ctx, "search.vector", attribute.Int("search.k", candidates))
+		start := time.Now()
+		vectorResults, vectorErr = runLeg(ctx, LegVector, t.vectorTimeout, func(ctx context.Context) ([]models.SearchResult, error) {
+			return s.vectorStore.Search(ctx, query, candidates, vector.SearchOptions{
+				EfSearch:      orDefault(req.EfSearch, t.efSearch),
+				Probes:        orDefault(req.Probes, t.probes),
+				IterativeScan: t.iterativeScan,
+				Filter:        flt,
+				Explain:       req.Explain,
+			})
+		})
+		ex.observe(stageVector, start)
+		span.SetAttributes(attribute.Int("search.results", len(vectorResults)))
+		tracing.End(span, vectorErr)
+	}()
+
+	// Run keyword search
+	go func() {
+		defer wg.Done()
+		ctx, span := tracing.Start(ctx, "search.keyword", attribute.Int("search.k", candidates))
+		start := time.Now()
+		keywordResults, keywordErr = runLeg(ctx, LegKeyword, t.keywordTimeout, func(ctx context.Context) ([]models.SearchResult, error) {
+			return s.searchClient.Search(ctx, query, candidates, KeywordOptions{
+				Filter:        flt,
+				ExpandedTerms: expandedTerms,
+				Explain:       req.Explain,
+			})
+		})
+		ex.observe(stageKeyword, start)
+		span.SetAttributes(attribute.Int("search.results", len(keywordResults)))
+		tracing.End(span, keywordErr)
+	}()
+
+	// Wait for both searches to complete
+	wg.Wait()
+
+	legs := map[string]models.LegStatus{
+		LegVector:  legStatus(vectorResults, vectorErr),
+		LegKeyword: legStatus(keywordResults, keywordErr),
+	}
+	for leg, status := range legs {
+		if status.Status != models.LegOK {
+			metrics.LegFailure(leg, status.Status)
+		}
+	}
+
+	if err := checkLegs(t.failureMode, ctx.Err(), vectorErr, keywordErr); err != nil {
+		return nil, legs, err
+	}
+	if vectorErr != nil || keywordErr != nil {
+		logging.FromContext(ctx, s.logger).Warn("retrieval leg failed, returning partial results",
+			"error", errors.Join(vectorErr, keywordErr))
+		fuser = singleLegFuser(fuser, vectorErr == nil)
+	}
+
+	metrics.ObserveResults(metrics.ResultsVector, len(vectorResults))
+	metrics.ObserveResults(metrics.ResultsKeyword, len(keywordResults))
+
+	// Fuse and deduplicate results
+	start := time.Now()
+	results := fuser.Fuse(vectorResults, keywordResults)
+	metrics.ObserveStage(metrics.StageFusion, start)
+	ex.observe(stageFusion, start)
+	explainFusion(results, fuser, query)
+	return results, legs, nil
+}
+
+// shouldRerank reports whether the rerank stage runs for this request.
+// The per-request flag can only enable reranking when a reranker is configured.
+func (s *Service) shouldRerank(t *tuning, req models.SearchRequest) bool {
+	if s.reranker == nil {
+		return false
+	}
+	if req.Rerank != nil {
+		return *req.Rerank
+	}
+	return t.rerankEnabled
+}
+
+// rerank reorders the top N results with the LLM reranker. The remaining
+// results keep their fused order after the reranked head. If reranking fails
+// and fallback is enabled, the fused order is returned unchanged. A non-nil
+// onRanking streams the model's answer and receives each ranking as it arrives.
+func (s *Service) rerank(
+	ctx context.Context,
+	t *tuning,
+	query string,
+	results []models.SearchResult,
+	ex *explainer,
+	onRanking func(rerank.Ranking),
+) ([]models.SearchResult, error) {
+	n := t.rerankTopN
+	if n > len(results) {
+		n = len(results)
+	}
+	if n == 0 {
+		return results, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.rerankTimeout)
+	defer cancel()
+
+	// Rerank a copy so the fused order survives a failure
+	head := make([]models.SearchResult, n)
+	copy(head, results[:n])
+	fusedOrder := make([]string, n)
+	for i, result := range head {
+		fusedOrder[i] = result.Tool.ID
+	}
+
+	ctx, span := tracing.Start(ctx, "search.rerank",
+		attribute.String("llm.model", s.reranker.Model()),
+		attribute.Int("search.rerank.candidates", n),
+	)
+	start := time.Now()
+	var reranked []models.SearchResult
+	var err error
+	if onRanking != nil {
+		reranked, err = s.reranker.RerankStream(ctx, query, head, onRanking)
+	} else {
+		reranked, err = s.reranker.Rerank(ctx, query, head)
+	}
+	metrics.ObserveStage(metrics.StageRerank, start)
+	tracing.End(span, err)
+	if err != nil {
+		metrics.DependencyError(metrics.DependencyOllama)
+		if t.rerankFallback {
+			logging.FromContext(ctx, s.logger).Warn("reranking failed, using fused order", "error", err)
+			ex.rerankFailed(err)
+			return results, nil
 		}
+		return nil, fmt.Errorf("rerank failed: %w", err)
 	}
-	
-	// Convert map to slice and sort by score
-	results := make([]models.SearchResult, 0, len(seen))
-	for _, result := range seen {
-		results = append(results, result)
-	}
-	
-	// Sort results by score in descending order
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
-	
-	return results
+
+	explainRerank(reranked, fusedOrder)
+	return append(reranked, results[n:]...), nil
 }
 
 func (s *Service) applyScoreThreshold(results []models.SearchResult, minScore float64) []models.SearchResult {
@@ -165,4 +564,20 @@ func (s *Service) applyScoreThreshold(results []models.SearchResult, minScore fl
 		}
 	}
 	return filtered
-} 
\ No newline at end of file
+}
+
+// orDefault returns v, or def when v is not positive
+func orDefault(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+// cursorKey returns the key cursors are signed with
+func (s *Service) cursorKey(t *tuning) []byte {
+	if t.cursorKey != nil {
+		return t.cursorKey
+	}
+	return s.processCursorKey
+}
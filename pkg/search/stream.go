@@ -0,0 +1,26 @@
+package search
+
+import (
+	"context"
+	"smartsearch/pkg/models"
+)
+
+// Events sent by SearchStream
+const (
+	// EventFused carries the fused page before reranking
+	EventFused = "fused"
+	// EventRerank carries one ranking from the reranker
+	EventRerank = "rerank"
+	// EventResults carries the final page
+	EventResults = "results"
+)
+
+// SearchStream runs a search like Search, sending progress to emit. The fused
+// page is sent as an EventFused once fusion and the score threshold finish;
+// when the rerank stage runs, each ranking follows as an EventRerank as soon
+// as the model writes it.
+// The returned response is the final page, which the caller sends as
+// EventResults.
+func (s *Service) SearchStream(ctx context.Context, req models.SearchRequest, emit func(event string, data any)) (*models.SearchResponse, error) {
+	return s.search(ctx, req, emit)
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"smartsearch/pkg/filter"
+	"smartsearch/pkg/logging"
+	"smartsearch/pkg/metrics"
+	"smartsearch/pkg/models"
+	"smartsearch/pkg/tracing"
+	"strings"
+	"time"
+)
+
+// queryPlan describes how a request is executed after query understanding
+type queryPlan struct {
+	filter        *filter.Filter
+	expandedTerms []string
+	subQueries    []string
+	understanding *models.QueryUnderstanding
+}
+
+// planQuery parses the user's filters and, when enabled, runs query
+// understanding to add expanded terms, sub-queries and extracted filters.
+// Understanding failures are logged and the request continues without it;
+// only invalid user filters are reported as errors.
+func (s *Service) planQuery(ctx context.Context, t *tuning, req models.SearchRequest) (*queryPlan, error) {
+	// Validate the user's filters on their own so LLM output can never cause a 400
+	if _, err := filter.Parse(req.Filters); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	plan := &queryPlan{}
+	filters := req.Filters
+
+	if s.shouldUnderstand(t, req) {
+		understanding, err := s.understand(ctx, t, req.Query)
+		if err != nil {
+			logging.FromContext(ctx, s.logger).Warn("query understanding failed, searching without it", "error", err)
+		} else {
+			plan.understanding = understanding
+			plan.expandedTerms = cleanTerms(understanding.ExpandedTerms, req.Query, 0)
+			plan.subQueries = cleanTerms(understanding.SubQueries, req.Query, t.maxSubQueries)
+			filters = mergeFilters(logging.FromContext(ctx, s.logger), req.Filters, understanding.Filters)
+		}
+	}
+
+	flt, err := filter.Parse(filters)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	plan.filter = flt
+
+	return plan, nil
+}
+
+// shouldUnderstand reports whether query understanding runs for this request
+func (s *Service) shouldUnderstand(t *tuning, req models.SearchRequest) bool {
+	if s.queryEngine == nil {
+		return false
+	}
+	if req.Understand != nil {
+		return *req.Understand
+	}
+	return t.understandEnabled
+}
+
+func (s *Service) understand(ctx context.Context, t *tuning, query string) (*models.QueryUnderstanding, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.understandTimeout)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "search.understand")
+	start := time.Now()
+	understanding, err := s.queryEngine.UnderstandQuery(ctx, query)
+	metrics.ObserveStage(metrics.StageUnderstanding, start)
+	tracing.End(span, err)
+	if err != nil {
+		metrics.DependencyError(metrics.DependencyOllama)
+	}
+	return understanding, err
+}
+
+// mergeFilters adds LLM-extracted filters to the user's filters. User filters
+// win on conflicts, and extracted fields that do not parse are dropped.
+func mergeFilters(logger *slog.Logger, user, extracted map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(user)+len(extracted))
+	for key, value := range user {
+		merged[key] = value
+	}
+
+	for key, value := range extracted {
+		if _, ok := merged[key]; ok {
+			continue
+		}
+		if _, err := filter.Parse(map[string]interface{}{key: value}); err != nil {
+			logger.Debug("ignoring extracted filter", "key", key, "error", err)
+			continue
+		}
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// cleanTerms trims and deduplicates terms, dropping any equal to the original
+// query. A positive limit caps the number of terms returned.
+func cleanTerms(terms []string, query string, limit int) []string {
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(query)): true}
+
+	var out []string
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		key := strings.ToLower(term)
+		if term == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, term)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// fuseQueryResults combines the hybrid results of the main query and its
+// sub-queries, keeping the best-scoring result for each tool
+func fuseQueryResults(lists [][]models.SearchResult) []models.SearchResult {
+	if len(lists) == 1 {
+		return lists[0]
+	}
+
+	best := make(map[string]models.SearchResult)
+	for _, list := range lists {
+		for _, result := range list {
+			if existing, ok := best[result.Tool.ID]; !ok || result.Score > existing.Score {
+				best[result.Tool.ID] = result
+			}
+		}
+	}
+
+	results := make([]models.SearchResult, 0, len(best))
+	for _, result := range best {
+		results = append(results, result)
+	}
+	sortResults(results)
+
+	return results
+}
@@ -0,0 +1,127 @@
+package search
+
+import (
+	"log/slog"
+	"reflect"
+	"smartsearch/pkg/models"
+	"strings"
+	"testing"
+)
+
+// ids lists result tool IDs in order
+func ids(results []models.SearchResult) string {
+	list := make([]string, len(results))
+	for i, result := range results {
+		list[i] = result.Tool.ID
+	}
+	return strings.Join(list, ",")
+}
+
+func TestMergeFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      map[string]interface{}
+		extracted map[string]interface{}
+		want      map[string]interface{}
+	}{
+		{name: "nothing", want: map[string]interface{}{}},
+		{
+			name: "user only",
+			user: map[string]interface{}{"category": "email"},
+			want: map[string]interface{}{"category": "email"},
+		},
+		{
+			name:      "extracted added",
+			user:      map[string]interface{}{"category": "email"},
+			extracted: map[string]interface{}{"tags": []interface{}{"pdf"}},
+			want:      map[string]interface{}{"category": "email", "tags": []interface{}{"pdf"}},
+		},
+		{
+			name:      "user wins on conflict",
+			user:      map[string]interface{}{"category": "email"},
+			extracted: map[string]interface{}{"category": "chat"},
+			want:      map[string]interface{}{"category": "email"},
+		},
+		{
+			name:      "invalid extracted field dropped",
+			extracted: map[string]interface{}{"category": 42.0, "tags": []interface{}{"pdf"}},
+			want:      map[string]interface{}{"tags": []interface{}{"pdf"}},
+		},
+		{
+			name:      "unknown extracted field dropped",
+			extracted: map[string]interface{}{"colour": "red"},
+			want:      map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeFilters(slog.Default(), tt.user, tt.extracted)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+		query string
+		limit int
+		want  []string
+	}{
+		{name: "none", query: "send email"},
+		{name: "trimmed", terms: []string{" mail ", "smtp"}, query: "send email", want: []string{"mail", "smtp"}},
+		{name: "blank dropped", terms: []string{"", "  ", "mail"}, query: "send email", want: []string{"mail"}},
+		{name: "duplicates ignore case", terms: []string{"Mail", "mail", "MAIL"}, query: "send email", want: []string{"Mail"}},
+		{name: "query dropped", terms: []string{"Send Email", "mail"}, query: " send email ", want: []string{"mail"}},
+		{name: "limit", terms: []string{"a", "b", "c"}, query: "q", limit: 2, want: []string{"a", "b"}},
+		{name: "limit counts kept terms", terms: []string{"a", "a", "q", "b", "c"}, query: "q", limit: 2, want: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cleanTerms(tt.terms, tt.query, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cleanTerms() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuseQueryResults(t *testing.T) {
+	tests := []struct {
+		name  string
+		lists [][]models.SearchResult
+		want  string
+	}{
+		{name: "single list unchanged", lists: [][]models.SearchResult{leg("b", 0.2, "a", 0.9)}, want: "b,a"},
+		{
+			name:  "best score per tool",
+			lists: [][]models.SearchResult{leg("a", 0.5, "b", 0.4), leg("b", 0.9, "c", 0.1)},
+			want:  "b,a,c",
+		},
+		{
+			name:  "ties by id",
+			lists: [][]models.SearchResult{leg("z", 0.5), leg("y", 0.5)},
+			want:  "y,z",
+		},
+		{name: "empty sub-query", lists: [][]models.SearchResult{leg("a", 0.5), nil}, want: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fuseQueryResults(tt.lists)
+			if ids(got) != tt.want {
+				t.Errorf("fuseQueryResults() = %s, want %s", ids(got), tt.want)
+			}
+		})
+	}
+
+	fused := fuseQueryResults([][]models.SearchResult{leg("a", 0.5), leg("a", 0.8)})
+	if len(fused) != 1 || fused[0].Score != 0.8 {
+		t.Errorf("kept %v, want the 0.8 result", fused)
+	}
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartsearch/internal/vector"
+	"smartsearch/pkg/models"
+	"smartsearch/pkg/search"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when the requested tool does not exist
+	ErrNotFound = errors.New("tool not found")
+	// ErrAlreadyExists is returned when creating a tool whose ID is taken
+	ErrAlreadyExists = errors.New("tool already exists")
+)
+
+// Service manages the tool registry, writing through to both the vector store
+// and the OpenSearch index so the two retrieval backends stay in sync.
+type Service struct {
+	vectorStore  *vector.VectorStore
+	searchClient *search.OpenSearchClient
+}
+
+func NewService(vectorStore *vector.VectorStore, searchClient *search.OpenSearchClient) *Service {
+	return &Service{
+		vectorStore:  vectorStore,
+		searchClient: searchClient,
+	}
+}
+
+// Get returns a single tool by ID
+func (s *Service) Get(ctx context.Context, id string) (*models.Tool, error) {
+	tool, err := s.vectorStore.GetTool(ctx, id)
+	if errors.Is(err, vector.ErrToolNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tool, nil
+}
+
+// List returns a page of tools, optionally restricted to a category
+func (s *Service) List(ctx context.Context, category string, limit, offset int) (*models.ToolListResponse, error) {
+	tools, total, err := s.vectorStore.ListTools(ctx, category, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ToolListResponse{
+		Tools:  tools,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// Create registers a new tool. It fails with ErrAlreadyExists if the ID is taken.
+func (s *Service) Create(ctx context.Context, tool models.Tool) (*models.Tool, error) {
+	if err := Validate(tool); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	tool.CreatedAt = now
+	tool.UpdatedAt = now
+
+	err := s.vectorStore.CreateTool(ctx, tool)
+	if errors.Is(err, vector.ErrToolExists) {
+		return nil, ErrAlreadyExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to index tool %s in PostgreSQL: %w", tool.ID, err)
+	}
+
+	// A failure here leaves the tool only in PostgreSQL; a PUT of the same
+	// tool repairs the keyword index
+	if err := s.searchClient.IndexTool(ctx, tool); err != nil {
+		return nil, fmt.Errorf("failed to index tool %s in OpenSearch: %w", tool.ID, err)
+	}
+	return &tool, nil
+}
+
+// Replace stores tool under id, creating it if it does not exist. The returned
+// bool reports whether a new tool was created.
+func (s *Service) Replace(ctx context.Context, id string, tool models.Tool) (*models.Tool, bool, error) {
+	if tool.ID == "" {
+		tool.ID = id
+	}
+	if tool.ID != id {
+		return nil, false, &ValidationError{Fields: []FieldError{
+			{Field: "id", Message: fmt.Sprintf("must match path id %q", id)},
+		}}
+	}
+	if err := Validate(tool); err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now().UTC()
+	created := false
+	existing, err := s.vectorStore.GetTool(ctx, id)
+	switch {
+	case errors.Is(err, vector.ErrToolNotFound):
+		created = true
+		tool.CreatedAt = now
+	case err != nil:
+		return nil, false, err
+	default:
+		tool.CreatedAt = existing.CreatedAt
+	}
+	tool.UpdatedAt = now
+
+	if err := s.write(ctx, tool); err != nil {
+		return nil, false, err
+	}
+	return &tool, created, nil
+}
+
+// Patch applies a partial update to an existing tool
+func (s *Service) Patch(ctx context.Context, id string, patch models.ToolPatch) (*models.Tool, error) {
+	tool, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Name != nil {
+		tool.Name = *patch.Name
+	}
+	if patch.Description != nil {
+		tool.Description = *patch.Description
+	}
+	if patch.Category != nil {
+		tool.Category = *patch.Category
+	}
+	if patch.Tags != nil {
+		tool.Tags = *patch.Tags
+	}
+	if patch.InputSchema != nil {
+		tool.InputSchema = *patch.InputSchema
+	}
+	if patch.OutputSchema != nil {
+		tool.OutputSchema = *patch.OutputSchema
+	}
+	if patch.Version != nil {
+		tool.Version = *patch.Version
+	}
+
+	if err := Validate(*tool); err != nil {
+		return nil, err
+	}
+	tool.UpdatedAt = time.Now().UTC()
+
+	if err := s.write(ctx, *tool); err != nil {
+		return nil, err
+	}
+	return tool, nil
+}
+
+// Delete removes a tool from both backends. OpenSearch goes first, where a
+// missing document counts as deleted, so a retry after a failed PostgreSQL
+// delete still finds the row and never leaves an orphaned keyword document.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	if err := s.searchClient.DeleteTool(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete tool from OpenSearch: %w", err)
+	}
+
+	if err := s.vectorStore.DeleteTool(ctx, id); err != nil {
+		if errors.Is(err, vector.ErrToolNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete tool from PostgreSQL: %w", err)
+	}
+
+	return nil
+}
+
+// write stores the tool in PostgreSQL (with its embedding) and then in OpenSearch.
+// Both writes are idempotent upserts, so a failed request can simply be retried.
+func (s *Service) write(ctx context.Context, tool models.Tool) error {
+	if err := s.vectorStore.IndexTool(ctx, tool); err != nil {
+		return fmt.Errorf("failed to index tool %s in PostgreSQL: %w", tool.ID, err)
+	}
+
+	if err := s.searchClient.IndexTool(ctx, tool); err != nil {
+		return fmt.Errorf("failed to index tool %s in OpenSearch: %w", tool.ID, err)
+	}
+
+	return nil
+}
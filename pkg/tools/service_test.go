@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"smartsearch/pkg/models"
+	"testing"
+)
+
+// These cases are all rejected before either backend is touched, so the
+// service runs without a vector store or OpenSearch client
+func TestServiceRejectsInvalidTools(t *testing.T) {
+	svc := NewService(nil, nil)
+	ctx := context.Background()
+
+	invalid := validTool()
+	invalid.Name = ""
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "create invalid", call: func() error {
+			_, err := svc.Create(ctx, invalid)
+			return err
+		}},
+		{name: "replace invalid", call: func() error {
+			_, _, err := svc.Replace(ctx, invalid.ID, invalid)
+			return err
+		}},
+		{name: "replace id mismatch", call: func() error {
+			_, _, err := svc.Replace(ctx, "other", validTool())
+			return err
+		}},
+		{name: "replace takes the path id", call: func() error {
+			tool := validTool()
+			tool.ID = ""
+			_, _, err := svc.Replace(ctx, "bad id", tool)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *ValidationError
+			if err := tt.call(); !errors.As(err, &validationErr) {
+				t.Errorf("error = %v, want a ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := Validate(models.Tool{Name: "n", Description: "d"})
+	if got, want := err.Error(), "invalid tool: id: is required"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"smartsearch/pkg/models"
+	"strings"
+)
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,127}$`)
+
+// schemaTypes are the JSON Schema types accepted at the top level of a tool schema
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// FieldError describes a single invalid field in a tool payload
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every problem found in a tool payload
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "invalid tool: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks that a tool is complete enough to be indexed
+func Validate(tool models.Tool) error {
+	var errs []FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if tool.ID == "" {
+		add("id", "is required")
+	} else if !idPattern.MatchString(tool.ID) {
+		add("id", "must be 1-128 characters of letters, digits, '.', '_', ':' or '-'")
+	}
+	if strings.TrimSpace(tool.Name) == "" {
+		add("name", "is required")
+	}
+	if strings.TrimSpace(tool.Description) == "" {
+		add("description", "is required")
+	}
+	for i, tag := range tool.Tags {
+		if strings.TrimSpace(tag) == "" {
+			add(fmt.Sprintf("tags[%d]", i), "must not be empty")
+		}
+	}
+	if msg := validateSchema(tool.InputSchema); msg != "" {
+		add("input_schema", "%s", msg)
+	}
+	if msg := validateSchema(tool.OutputSchema); msg != "" {
+		add("output_schema", "%s", msg)
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+// validateSchema performs a shallow sanity check of a JSON Schema document
+func validateSchema(schema map[string]interface{}) string {
+	if schema == nil {
+		return ""
+	}
+
+	if t, ok := schema["type"]; ok {
+		name, ok := t.(string)
+		if !ok || !schemaTypes[name] {
+			return fmt.Sprintf("has unsupported type %v", t)
+		}
+	}
+	if props, ok := schema["properties"]; ok {
+		if _, ok := props.(map[string]interface{}); !ok {
+			return "properties must be an object"
+		}
+	}
+	if required, ok := schema["required"]; ok {
+		if _, ok := required.([]interface{}); !ok {
+			return "required must be an array"
+		}
+	}
+
+	return ""
+}
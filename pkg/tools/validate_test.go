@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"errors"
+	"reflect"
+	"smartsearch/pkg/models"
+	"strings"
+	"testing"
+)
+
+func validTool() models.Tool {
+	return models.Tool{
+		ID:          "weather.get_forecast",
+		Name:        "Get forecast",
+		Description: "Returns the weather forecast for a city",
+		Tags:        []string{"weather"},
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+			"required":   []interface{}{"city"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*models.Tool)
+		want   []string // invalid fields, in order
+	}{
+		{name: "valid", modify: func(*models.Tool) {}},
+		{name: "no schemas", modify: func(tool *models.Tool) { tool.InputSchema = nil }},
+		{name: "missing id", modify: func(tool *models.Tool) { tool.ID = "" }, want: []string{"id"}},
+		{name: "bad id characters", modify: func(tool *models.Tool) { tool.ID = "has space" }, want: []string{"id"}},
+		{name: "id starts with punctuation", modify: func(tool *models.Tool) { tool.ID = "-tool" }, want: []string{"id"}},
+		{name: "id too long", modify: func(tool *models.Tool) { tool.ID = strings.Repeat("a", 129) }, want: []string{"id"}},
+		{name: "id at max length", modify: func(tool *models.Tool) { tool.ID = strings.Repeat("a", 128) }},
+		{name: "blank name", modify: func(tool *models.Tool) { tool.Name = "  " }, want: []string{"name"}},
+		{name: "blank tag", modify: func(tool *models.Tool) { tool.Tags = []string{"ok", " "} }, want: []string{"tags[1]"}},
+		{name: "unknown schema type", modify: func(tool *models.Tool) { tool.InputSchema["type"] = "map" }, want: []string{"input_schema"}},
+		{name: "non-string schema type", modify: func(tool *models.Tool) { tool.InputSchema["type"] = 3.0 }, want: []string{"input_schema"}},
+		{name: "properties not an object", modify: func(tool *models.Tool) { tool.InputSchema["properties"] = []interface{}{} }, want: []string{"input_schema"}},
+		{name: "required not an array", modify: func(tool *models.Tool) { tool.InputSchema["required"] = "city" }, want: []string{"input_schema"}},
+		{name: "bad output schema", modify: func(tool *models.Tool) {
+			tool.OutputSchema = map[string]interface{}{"type": "table"}
+		}, want: []string{"output_schema"}},
+		{name: "every problem reported", modify: func(tool *models.Tool) {
+			tool.ID, tool.Name, tool.Description = "", "", ""
+		}, want: []string{"id", "name", "description"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := validTool()
+			tt.modify(&tool)
+
+			err := Validate(tool)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() = %v, want a ValidationError", err)
+			}
+			var fields []string
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.want) {
+				t.Errorf("invalid fields = %v, want %v", fields, tt.want)
+			}
+		})
+	}
+}
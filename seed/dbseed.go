@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"smartsearch/internal/vector"
@@ -25,10 +26,11 @@ func SeedDatabase(
 	db *sql.DB,
 	opensearchClient *opensearch.Client,
 	cfg *config.Config,
+	logger *slog.Logger,
 ) error {
 	// Initialize vector store and search client
-	vectorStore := vector.NewVectorStore(db, cfg.Ollama.URL, cfg.Ollama.EmbedModel, cfg)
-	searchClient := search.NewOpenSearchClient(opensearchClient, cfg.OpenSearch.Index)
+	vectorStore := vector.NewVectorStore(db, cfg.Ollama.URL, cfg.Ollama.EmbedModel, cfg, logger)
+	searchClient := search.NewOpenSearchClient(opensearchClient, cfg.OpenSearch.Index, cfg, logger)
 
 	// Initialize OpenSearch index
 	if err := searchClient.CreateOrUpdateIndex(ctx); err != nil {
@@ -53,19 +55,18 @@ func SeedDatabase(
 		seedData.Tools[i].UpdatedAt = now
 	}
 
-	// Store tools in both PostgreSQL and OpenSearch
-	for _, tool := range seedData.Tools {
-		// Store in PostgreSQL with vector embedding
-		if err := vectorStore.IndexTool(ctx, tool); err != nil {
-			return fmt.Errorf("failed to index tool %s in PostgreSQL: %w", tool.ID, err)
-		}
+	// Store in PostgreSQL with vector embeddings, embedded in batches
+	if err := vectorStore.IndexTools(ctx, seedData.Tools); err != nil {
+		return fmt.Errorf("failed to index tools in PostgreSQL: %w", err)
+	}
 
-		// Store in OpenSearch
+	// Store in OpenSearch
+	for _, tool := range seedData.Tools {
 		if err := searchClient.IndexTool(ctx, tool); err != nil {
 			return fmt.Errorf("failed to index tool %s in OpenSearch: %w", tool.ID, err)
 		}
 	}
 
-	fmt.Printf("Successfully seeded %d tools\n", len(seedData.Tools))
+	logger.Info("seeded tools", "count", len(seedData.Tools))
 	return nil
 }